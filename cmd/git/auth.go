@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+const (
+	// GitTokenUsername - HTTPS username used with a token when no username is configured
+	// GitHub, GitLab and Bitbucket accept any non-empty username together with a token
+	GitTokenUsername = "x-access-token"
+	// GitDefaultSSHUser - SSH user used when SSH URL does not specify one
+	GitDefaultSSHUser = "git"
+)
+
+// gitCredentials - credentials used by git CLI and go-git
+// they are kept out of exported configuration fields, so they are never printed together with the configuration
+type gitCredentials struct {
+	username   string // HTTPS basic auth username
+	password   string // HTTPS basic auth password or token
+	sshKey     string // SSH private key file
+	knownHosts string // SSH known_hosts file, default ssh known_hosts are used when not set
+}
+
+// RedactURL - remove credentials from URL, SSH user names and scp-like URLs (git@host:org/repo) are kept as they are
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return rawURL
+	}
+	if u.Scheme == "ssh" {
+		u.User = url.User(u.User.Username())
+		return u.String()
+	}
+	u.User = nil
+	return u.String()
+}
+
+// isHTTPURL - is URL using http or https scheme
+func isHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// readSecretFile - read credential file contents without surrounding whitespace
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(os.ExpandEnv(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// netrcCredentials - return login and password for a given host from netrc file, "default" entry is used as a fallback
+func netrcCredentials(data []byte, host string) (login, password string, found bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Split(bufio.ScanWords)
+	var (
+		inMacro    bool
+		defLogin   string
+		defPasswd  string
+		defFound   bool
+		curLogin   string
+		curPasswd  string
+		curIsMatch bool
+		curIsDef   bool
+	)
+	flush := func() {
+		if curIsMatch && !found {
+			login, password, found = curLogin, curPasswd, true
+		}
+		if curIsDef && !defFound {
+			defLogin, defPasswd, defFound = curLogin, curPasswd, true
+		}
+		curLogin, curPasswd, curIsMatch, curIsDef = "", "", false, false
+	}
+	for scanner.Scan() {
+		token := scanner.Text()
+		if inMacro {
+			// macro definitions end with an empty line, words scanner cannot see it, so skip until next entry
+			if token != "machine" && token != "default" {
+				continue
+			}
+			inMacro = false
+		}
+		switch token {
+		case "machine":
+			flush()
+			if scanner.Scan() {
+				curIsMatch = strings.EqualFold(scanner.Text(), host)
+			}
+		case "default":
+			flush()
+			curIsDef = true
+		case "login":
+			if scanner.Scan() {
+				curLogin = scanner.Text()
+			}
+		case "password":
+			if scanner.Scan() {
+				curPasswd = scanner.Text()
+			}
+		case "macdef":
+			inMacro = true
+		}
+	}
+	flush()
+	if !found && defFound {
+		login, password, found = defLogin, defPasswd, true
+	}
+	return
+}
+
+// resolveCredentials - collect credentials from configured files, environment and URL
+// credentials embedded in URL are moved out of it, so they never reach logs, reports and payloads
+func (j *DSGit) resolveCredentials() (err error) {
+	c := gitCredentials{}
+	if j.credentials != nil {
+		c = *j.credentials
+	}
+	// manifest entries share configuration, each of them gets its own copy
+	j.credentials = &c
+	u, e := url.Parse(j.URL)
+	// configured HTTPS credentials are only sent to the credentials host, a manifest lists repositories of many hosts
+	scoped := j.Manifest == "" && j.CredentialsHost != "" && (e != nil || !strings.EqualFold(u.Hostname(), j.CredentialsHost))
+	if scoped {
+		c.username, c.password = "", ""
+	}
+	if j.Username != "" && !scoped {
+		c.username = j.Username
+	}
+	if j.PasswordFile != "" && !scoped {
+		c.password, err = readSecretFile(j.PasswordFile)
+		if err != nil {
+			err = fmt.Errorf("cannot read password file: %+v", err)
+			return
+		}
+	}
+	if j.Manifest != "" && j.CredentialsHost == "" && c.password != "" {
+		err = fmt.Errorf("manifest mode needs credentials host set together with token or password, so they are not sent to every repository host")
+		return
+	}
+	if e == nil && u.User != nil && isHTTPURL(j.URL) {
+		if c.username == "" {
+			c.username = u.User.Username()
+		}
+		if password, ok := u.User.Password(); ok && c.password == "" {
+			c.password = password
+		}
+		j.URL = RedactURL(j.URL)
+	}
+	if c.password == "" && j.Netrc != "" && e == nil && isHTTPURL(j.URL) {
+		data, e := os.ReadFile(os.ExpandEnv(j.Netrc))
+		if e != nil {
+			err = fmt.Errorf("cannot read netrc file: %+v", e)
+			return
+		}
+		// configured username is kept, netrc supplies the missing password
+		login, password, found := netrcCredentials(data, u.Hostname())
+		if found {
+			if c.username == "" {
+				c.username = login
+			}
+			c.password = password
+		}
+	}
+	if c.password != "" && c.username == "" {
+		c.username = GitTokenUsername
+	}
+	if j.SSHKey != "" {
+		c.sshKey = os.ExpandEnv(j.SSHKey)
+		if _, err = os.Stat(c.sshKey); err != nil {
+			err = fmt.Errorf("cannot access SSH key: %+v", err)
+			return
+		}
+	}
+	if j.SSHKnownHosts != "" {
+		c.knownHosts = os.ExpandEnv(j.SSHKnownHosts)
+		if _, err = os.Stat(c.knownHosts); err != nil {
+			err = fmt.Errorf("cannot access SSH known_hosts: %+v", err)
+			return
+		}
+	}
+	return
+}
+
+// gitAuthEnv - return env with credentials passed to git CLI, they are never put on its command line
+// HTTPS credentials are sent as http.<scheme>://<host>/.extraHeader via GIT_CONFIG_* variables, SSH key via GIT_SSH_COMMAND
+func (j *DSGit) gitAuthEnv(env map[string]string) map[string]string {
+	res := make(map[string]string, len(env)+4)
+	for k, v := range env {
+		res[k] = v
+	}
+	c := j.credentials
+	if c == nil {
+		return res
+	}
+	source := j.cloneURL()
+	if u, err := url.Parse(source); err == nil && c.username != "" && isHTTPURL(source) {
+		basic := base64.StdEncoding.EncodeToString([]byte(c.username + ":" + c.password))
+		// config entries already passed via env are kept, the header is only sent to the clone URL's host
+		count, ok := res["GIT_CONFIG_COUNT"]
+		if !ok {
+			count = os.Getenv("GIT_CONFIG_COUNT")
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			n = 0
+		}
+		res[fmt.Sprintf("GIT_CONFIG_KEY_%d", n)] = "http." + u.Scheme + "://" + u.Host + "/.extraHeader"
+		res[fmt.Sprintf("GIT_CONFIG_VALUE_%d", n)] = "Authorization: Basic " + basic
+		res["GIT_CONFIG_COUNT"] = strconv.Itoa(n + 1)
+	}
+	if c.sshKey != "" {
+		cmd := "ssh -i " + shellQuote(c.sshKey) + " -o IdentitiesOnly=yes -o BatchMode=yes"
+		if c.knownHosts != "" {
+			cmd += " -o UserKnownHostsFile=" + shellQuote(c.knownHosts) + " -o StrictHostKeyChecking=yes"
+		}
+		res["GIT_SSH_COMMAND"] = cmd
+	}
+	return res
+}
+
+// shellQuote - quote a path for GIT_SSH_COMMAND which is run by shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// gitAuth - return go-git auth method, nil when no credentials are configured
+func (j *DSGit) gitAuth() (auth transport.AuthMethod, err error) {
+	c := j.credentials
+	if c == nil {
+		return
+	}
+	source := j.cloneURL()
+	if c.sshKey != "" && !isHTTPURL(source) && !isLocalSource(source) {
+		user := GitDefaultSSHUser
+		if u, e := url.Parse(source); e == nil && u.User != nil && u.User.Username() != "" {
+			user = u.User.Username()
+		} else if i := strings.Index(source, "@"); i > 0 && !strings.Contains(source[:i], "/") {
+			user = source[:i]
+		}
+		keys, e := gitssh.NewPublicKeysFromFile(user, c.sshKey, "")
+		if e != nil {
+			err = fmt.Errorf("cannot load SSH key: %+v", e)
+			return
+		}
+		var files []string
+		if c.knownHosts != "" {
+			files = append(files, c.knownHosts)
+		}
+		keys.HostKeyCallback, err = gitssh.NewKnownHostsCallback(files...)
+		if err != nil {
+			return
+		}
+		auth = keys
+		return
+	}
+	if c.username != "" && isHTTPURL(source) {
+		auth = &http.BasicAuth{Username: c.username, Password: c.password}
+	}
+	return
+}
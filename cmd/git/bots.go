@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/LF-Engineering/lfx-event-schema/service/insights"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// GitDefaultBotNamePatterns - contributor names of known bots and automation accounts
+	GitDefaultBotNamePatterns = []string{
+		`(?i)\[bot\]$`,
+		`(?i)(^|[\s\-_.])bot$`,
+		`(?i)^(dependabot|renovate|renovate-bot|greenkeeper|snyk-bot|github-actions|github action|mergify|imgbot|pre-commit-ci|allcontributors|semantic-release-bot|codecov|coveralls)$`,
+		`(?i)^(k8s-ci-robot|k8s-merge-robot|openshift-ci-robot|openshift-merge-robot|jenkins|jenkins-x-bot|zuul|travis ci|circleci|gitlab ci|weblate|transifex|copybara-service)$`,
+	}
+	// GitDefaultBotEmailPatterns - contributor emails of known bots and automation accounts
+	GitDefaultBotEmailPatterns = []string{
+		`(?i)\[bot\]@`,
+		`(?i)^(no-?reply|do-?not-?reply|bot|ci|build|builder|jenkins|automation)@`,
+		`(?i)^(dependabot|renovate|github-actions|k8s-ci-robot|openshift-ci-robot)(\+.*)?@`,
+		`(?i)@(dependabot\.com|renovateapp\.com|travis-ci\.org|travis-ci\.com|circleci\.com|weblate\.org)$`,
+	}
+)
+
+// BotPatterns - bot detection patterns file, patterns are added to the built-in ones
+type BotPatterns struct {
+	Names  []string `yaml:"names" json:"names"`
+	Emails []string `yaml:"emails" json:"emails"`
+	// Exclude - names or emails (exact, case insensitive) never classified as bots, for example humans named "*-bot"
+	Exclude []string `yaml:"exclude" json:"exclude"`
+}
+
+// BotClassifier - classify contributors as bots by name and email patterns
+type BotClassifier struct {
+	names   []*regexp.Regexp
+	emails  []*regexp.Regexp
+	exclude map[string]struct{}
+}
+
+// Contributor - insights contributor extended with bot flag, so consumers can exclude automation accounts
+type Contributor struct {
+	insights.Contributor
+	IsBot bool `json:"is_bot"`
+}
+
+// NewBotClassifier - create classifier from built-in patterns and optional YAML/JSON patterns file
+func NewBotClassifier(path string) (c *BotClassifier, err error) {
+	patterns := BotPatterns{Names: GitDefaultBotNamePatterns, Emails: GitDefaultBotEmailPatterns}
+	if path != "" {
+		var data []byte
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		var extra BotPatterns
+		if err = yaml.Unmarshal(data, &extra); err != nil {
+			err = fmt.Errorf("cannot parse bot patterns %s: %+v", path, err)
+			return
+		}
+		patterns.Names = append(append([]string{}, patterns.Names...), extra.Names...)
+		patterns.Emails = append(append([]string{}, patterns.Emails...), extra.Emails...)
+		patterns.Exclude = extra.Exclude
+	}
+	c = &BotClassifier{exclude: make(map[string]struct{})}
+	compile := func(exprs []string) ([]*regexp.Regexp, error) {
+		res := make([]*regexp.Regexp, 0, len(exprs))
+		for _, expr := range exprs {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bot pattern %s: %+v", expr, err)
+			}
+			res = append(res, re)
+		}
+		return res, nil
+	}
+	if c.names, err = compile(patterns.Names); err != nil {
+		return
+	}
+	if c.emails, err = compile(patterns.Emails); err != nil {
+		return
+	}
+	for _, exclude := range patterns.Exclude {
+		c.exclude[strings.ToLower(strings.TrimSpace(exclude))] = struct{}{}
+	}
+	return
+}
+
+// IsBot - is a contributor with a given name and email a bot or automation account
+func (c *BotClassifier) IsBot(name, email string) bool {
+	if c == nil {
+		return false
+	}
+	name, email = strings.TrimSpace(name), strings.TrimSpace(email)
+	if _, ok := c.exclude[strings.ToLower(name)]; ok && name != "" {
+		return false
+	}
+	if _, ok := c.exclude[strings.ToLower(email)]; ok && email != "" {
+		return false
+	}
+	for _, re := range c.names {
+		if name != "" && re.MatchString(name) {
+			return true
+		}
+	}
+	for _, re := range c.emails {
+		if email != "" && re.MatchString(email) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyContributors - return contributors with bot flag set
+func (j *DSGit) classifyContributors(contributors []insights.Contributor) []Contributor {
+	res := make([]Contributor, 0, len(contributors))
+	for _, contributor := range contributors {
+		res = append(res, Contributor{
+			Contributor: contributor,
+			IsBot:       j.botClassifier.IsBot(contributor.Identity.Name, contributor.Identity.Email),
+		})
+	}
+	return res
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsBot(t *testing.T) {
+	var testCases = []struct {
+		name         string
+		contributor  string
+		email        string
+		expected     bool
+		withPatterns bool
+	}{
+		{name: "github app", contributor: "dependabot[bot]", email: "49699333+dependabot[bot]@users.noreply.github.com", expected: true},
+		{name: "github app name only", contributor: "Dependabot[bot]", expected: true},
+		{name: "github app email only", email: "41898282+github-actions[bot]@users.noreply.github.com", expected: true},
+		{name: "known bot name", contributor: "renovate-bot", email: "bot@renovateapp.com", expected: true},
+		{name: "known bot name with other email", contributor: "renovate-bot", email: "team@example.com", expected: true},
+		{name: "known automation account", contributor: "k8s-ci-robot", expected: true},
+		{name: "bot suffix after separator", contributor: "release_bot", expected: true},
+		{name: "bot word", contributor: "Jane Bot", expected: true},
+		{name: "noreply email", contributor: "Build System", email: "noreply@example.com", expected: true},
+		{name: "bot email plus address", contributor: "Renovate", email: "renovate+123@example.com", expected: true},
+		{name: "bot service domain", contributor: "Weblate", email: "hosted@weblate.org", expected: true},
+		{name: "bot inside a word", contributor: "Abbot", email: "abbot@example.com", expected: false},
+		{name: "bot prefix", contributor: "Botond Kiss", email: "botond@example.com", expected: false},
+		{name: "robot in name", contributor: "Robotics Team", email: "robotics@example.com", expected: false},
+		{name: "human", contributor: "Jane Doe", email: "jane@example.com", expected: false},
+		{name: "users noreply email of a human", contributor: "Jane Doe", email: "123+jane@users.noreply.github.com", expected: false},
+		{name: "empty", expected: false},
+		{name: "operator name pattern", contributor: "acme-release", email: "release@acme.example.com", expected: true, withPatterns: true},
+		{name: "operator email pattern", contributor: "Release", email: "deploy@ci.acme.example.com", expected: true, withPatterns: true},
+		{name: "operator excluded name", contributor: "Jane Bot", email: "jane@example.com", expected: false, withPatterns: true},
+		{name: "operator excluded email", contributor: "ci-bot", email: "CI-Bot@Example.com", expected: false, withPatterns: true},
+		{name: "built-in patterns kept", contributor: "dependabot[bot]", expected: true, withPatterns: true},
+		{name: "other humans with operator patterns", contributor: "John Roe", email: "john@acme.example.com", expected: false, withPatterns: true},
+	}
+	defaults, err := NewBotClassifier("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "bots.yaml")
+	patterns := "names:\n  - '(?i)^acme-release$'\nemails:\n  - '(?i)@ci\\.acme\\.example\\.com$'\nexclude:\n  - jane bot\n  - ci-bot@example.com\n"
+	if err = os.WriteFile(path, []byte(patterns), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	operator, err := NewBotClassifier(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := defaults
+			if tc.withPatterns {
+				c = operator
+			}
+			if got := c.IsBot(tc.contributor, tc.email); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+	var none *BotClassifier
+	if none.IsBot("dependabot[bot]", "") {
+		t.Errorf("expected no bots without classifier")
+	}
+}
+
+func TestNewBotClassifierErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("names:\n  - '('\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	malformed := filepath.Join(dir, "malformed.yaml")
+	if err := os.WriteFile(malformed, []byte("names: [\n"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{invalid, malformed, filepath.Join(dir, "missing.yaml")} {
+		if _, err := NewBotClassifier(path); err == nil {
+			t.Errorf("expected error for %s", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// CacheBackendS3 - commits cache stored in S3 (shared cache.Manager)
+	CacheBackendS3 = "s3"
+	// CacheBackendLocal - commits cache stored in a local directory
+	CacheBackendLocal = "local"
+	// CacheBackendMemory - commits cache kept in memory only, lost when process exits
+	CacheBackendMemory = "memory"
+	// LastSyncCacheFile - name of last sync file used by non-S3 cache backends
+	LastSyncCacheFile = "last-sync.json"
+)
+
+// CacheProvider - storage for commits cache files and last sync data
+// It is implemented by the shared S3 cache.Manager, LocalCache and MemoryCache
+type CacheProvider interface {
+	GetLastSyncFile(key string) ([]byte, error)
+	SetLastSyncFile(key string, content []byte) error
+	GetFileByKey(key, name string) ([]byte, error)
+	UpdateFileByKey(key, name string, content []byte) error
+	UpdateMultiPartFileByKey(key, path string) error
+}
+
+// LocalCache - CacheProvider storing files in <Path>/<key>/<name>
+type LocalCache struct {
+	Path string
+}
+
+// NewLocalCache - creates local directory cache provider
+func NewLocalCache(path string) (*LocalCache, error) {
+	if path == "" {
+		return nil, fmt.Errorf("local cache path must be set")
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalCache{Path: path}, nil
+}
+
+// GetLastSyncFile - return last sync data, empty JSON object when there was no sync yet
+func (c *LocalCache) GetLastSyncFile(key string) ([]byte, error) {
+	data, err := c.GetFileByKey(key, LastSyncCacheFile)
+	if os.IsNotExist(err) {
+		return []byte("{}"), nil
+	}
+	return data, err
+}
+
+// SetLastSyncFile - store last sync data
+func (c *LocalCache) SetLastSyncFile(key string, content []byte) error {
+	return c.UpdateFileByKey(key, LastSyncCacheFile, content)
+}
+
+// GetFileByKey - return cache file contents
+func (c *LocalCache) GetFileByKey(key, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(c.Path, key, name))
+}
+
+// UpdateFileByKey - replace cache file contents, file is written to a temporary file first and then renamed
+func (c *LocalCache) UpdateFileByKey(key, name string, content []byte) error {
+	dir := filepath.Join(c.Path, key)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// UpdateMultiPartFileByKey - store a local file under its base name
+func (c *LocalCache) UpdateMultiPartFileByKey(key, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.UpdateFileByKey(key, filepath.Base(path), content)
+}
+
+// MemoryCache - CacheProvider keeping all files in memory
+type MemoryCache struct {
+	mtx   *sync.RWMutex
+	files map[string]map[string][]byte
+}
+
+// NewMemoryCache - creates in-memory cache provider
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		mtx:   &sync.RWMutex{},
+		files: make(map[string]map[string][]byte),
+	}
+}
+
+// GetLastSyncFile - return last sync data, empty JSON object when there was no sync yet
+func (c *MemoryCache) GetLastSyncFile(key string) ([]byte, error) {
+	data, err := c.GetFileByKey(key, LastSyncCacheFile)
+	if err != nil {
+		return []byte("{}"), nil
+	}
+	return data, nil
+}
+
+// SetLastSyncFile - store last sync data
+func (c *MemoryCache) SetLastSyncFile(key string, content []byte) error {
+	return c.UpdateFileByKey(key, LastSyncCacheFile, content)
+}
+
+// GetFileByKey - return cache file contents, missing file error satisfies os.IsNotExist like LocalCache's one
+func (c *MemoryCache) GetFileByKey(key, name string) ([]byte, error) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	content, ok := c.files[key][name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: key + "/" + name, Err: os.ErrNotExist}
+	}
+	return append([]byte{}, content...), nil
+}
+
+// UpdateFileByKey - replace cache file contents
+func (c *MemoryCache) UpdateFileByKey(key, name string, content []byte) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.files[key]; !ok {
+		c.files[key] = make(map[string][]byte)
+	}
+	c.files[key][name] = append([]byte{}, content...)
+	return nil
+}
+
+// UpdateMultiPartFileByKey - store a local file under its base name
+func (c *MemoryCache) UpdateMultiPartFileByKey(key, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return c.UpdateFileByKey(key, filepath.Base(path), content)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheProviders(t *testing.T) {
+	var testCases = []struct {
+		name  string
+		cache func(t *testing.T) CacheProvider
+	}{
+		{
+			name: "local",
+			cache: func(t *testing.T) CacheProvider {
+				c, err := NewLocalCache(t.TempDir())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return c
+			},
+		},
+		{
+			name: "memory",
+			cache: func(t *testing.T) CacheProvider {
+				return NewMemoryCache()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache(t)
+			key := "github.com/org/repo"
+
+			content, err := c.GetLastSyncFile(key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != "{}" {
+				t.Errorf("expected empty last sync file, got %s", content)
+			}
+			if err = c.SetLastSyncFile(key, []byte(`{"last_sync":"2023-01-01"}`)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err = c.GetLastSyncFile(key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != `{"last_sync":"2023-01-01"}` {
+				t.Errorf("expected last sync file to round-trip, got %s", content)
+			}
+
+			_, err = c.GetFileByKey(key, "missing.csv")
+			if !os.IsNotExist(err) {
+				t.Errorf("expected not exist error for a missing file, got %v", err)
+			}
+			_, err = c.GetFileByKey("github.com/org/other", LastSyncCacheFile)
+			if !os.IsNotExist(err) {
+				t.Errorf("expected not exist error for a missing key, got %v", err)
+			}
+
+			data := []byte("sha,date\na,2023-01-01\n")
+			if err = c.UpdateFileByKey(key, "commits-cache-2023-1.csv", data); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// caller's buffer must not alias the stored contents
+			data[0] = 'X'
+			content, err = c.GetFileByKey(key, "commits-cache-2023-1.csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != "sha,date\na,2023-01-01\n" {
+				t.Errorf("expected file to round-trip, got %s", content)
+			}
+			content[0] = 'Y'
+			if err = c.UpdateFileByKey(key, "commits-cache-2023-1.csv", []byte("sha,date\nb,2023-02-01\n")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err = c.GetFileByKey(key, "commits-cache-2023-1.csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != "sha,date\nb,2023-02-01\n" {
+				t.Errorf("expected file to be overwritten, got %s", content)
+			}
+
+			path := filepath.Join(t.TempDir(), "commits-cache-2023-2.csv")
+			if err = os.WriteFile(path, []byte("sha,date\nc,2023-08-01\n"), 0644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err = c.UpdateMultiPartFileByKey(key, path); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err = c.GetFileByKey(key, "commits-cache-2023-2.csv")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != "sha,date\nc,2023-08-01\n" {
+				t.Errorf("expected multipart file stored under its base name, got %s", content)
+			}
+			if err = c.UpdateMultiPartFileByKey(key, filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+				t.Errorf("expected error for a missing multipart file")
+			}
+		})
+	}
+}
+
+func TestLocalCacheAtomicUpdate(t *testing.T) {
+	if _, err := NewLocalCache(""); err == nil {
+		t.Errorf("expected error for an empty path")
+	}
+	c, err := NewLocalCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := "github.com/org/repo"
+	dir := filepath.Join(c.Path, key)
+	tmpFiles := func() []string {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		files := []string{}
+		for _, entry := range entries {
+			if strings.Contains(entry.Name(), ".tmp-") {
+				files = append(files, entry.Name())
+			}
+		}
+		return files
+	}
+
+	if err = c.SetLastSyncFile(key, []byte("{}")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = c.UpdateFileByKey(key, "tags.csv", []byte("tag\nv1.0.0\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "tags.csv"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(content) != "tag\nv1.0.0\n" {
+		t.Errorf("expected file written to <path>/<key>/<name>, got %s", content)
+	}
+	if files := tmpFiles(); len(files) > 0 {
+		t.Errorf("expected no temporary files left, got %v", files)
+	}
+
+	// rename onto a non-empty directory fails, the temporary file must be removed
+	if err = os.MkdirAll(filepath.Join(dir, "busy.csv", "sub"), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = c.UpdateFileByKey(key, "busy.csv", []byte("x")); err == nil {
+		t.Errorf("expected error renaming onto a directory")
+	}
+	if files := tmpFiles(); len(files) > 0 {
+		t.Errorf("expected no temporary files left after a failed update, got %v", files)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GitCancelledExitCode - exit code of a sync stopped by a signal, 128 + SIGTERM like a shell reports it
+	GitCancelledExitCode = 143
+)
+
+var (
+	// ErrSyncCancelled - sync was stopped by a signal after flushing commits processed so far
+	ErrSyncCancelled = errors.New("sync cancelled")
+	// GitShutdownSignals - signals stopping the sync gracefully, ECS sends SIGTERM when it stops a task
+	GitShutdownSignals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+)
+
+// signalContext - return context cancelled on the first shutdown signal
+// the second signal is handled by Go default handler and terminates the process immediately
+func signalContext() (context.Context, context.CancelFunc) {
+	cancelCtx, stop := signal.NotifyContext(context.Background(), GitShutdownSignals...)
+	go func() {
+		<-cancelCtx.Done()
+		// restore default handling, so the second signal is not swallowed
+		stop()
+	}()
+	return cancelCtx, stop
+}
+
+// logCancelled - log that the sync stops, commits processed so far are still flushed
+func (j *DSGit) logCancelled(operation string) {
+	j.log.WithFields(logrus.Fields{"operation": operation}).Warning("shutdown signal received, flushing processed commits")
+}
+
+// syncCancelled - is the sync cancelled, once it is no new commits are processed
+func syncCancelled(cancelCtx context.Context) bool {
+	return cancelCtx.Err() != nil
+}
+
+// checkCancelled - return ErrSyncCancelled when the sync was cancelled during a phase before the commits walk
+// nothing was processed yet, so there is nothing to flush and last sync is not saved
+func (j *DSGit) checkCancelled(cancelCtx context.Context, operation string) error {
+	if !syncCancelled(cancelCtx) {
+		return nil
+	}
+	j.log.WithFields(logrus.Fields{"operation": operation}).Warning("shutdown signal received before walking commits, stopping")
+	return ErrSyncCancelled
+}
+
+// execCommand - execute command the same way shared.ExecCommand does, but kill it when the sync is cancelled
+// clone and fetch of big repositories can take long, so they must not delay the shutdown
+func execCommand(cancelCtx context.Context, cmdLine []string, dir string, env map[string]string) (sout, serr string, err error) {
+	cmd := exec.CommandContext(cancelCtx, cmdLine[0], cmdLine[1:]...)
+	cmd.Dir = dir
+	if env != nil {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdout = &stdOut
+	cmd.Stderr = &stdErr
+	err = cmd.Run()
+	sout, serr = stdOut.String(), stdErr.String()
+	if err != nil && syncCancelled(cancelCtx) {
+		err = ErrSyncCancelled
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// syncCheckpoint - frontier of published commits, saved in the last sync file
+// all commits walked in windows before Window are published, Published lists commits of Window and later windows
+// that are published too, so a restarted sync walks from Window and skips them: no gaps and no duplicates
+type syncCheckpoint struct {
+	Window    time.Time `json:"window"`
+	Published []string  `json:"published,omitempty"`
+}
+
+// checkpointState - commits walked by the current sync, used to compute its checkpoint
+type checkpointState struct {
+	window    time.Time            // start of the committer date window currently walked
+	pending   map[string]time.Time // commits processed but not published yet, with the window they were walked in
+	published map[string]time.Time // published commits not older than the frontier, with the window they were walked in
+	mtx       *sync.Mutex
+}
+
+// newCheckpointState - return empty checkpoint state
+func newCheckpointState() *checkpointState {
+	return &checkpointState{
+		pending:   make(map[string]time.Time),
+		published: make(map[string]time.Time),
+		mtx:       &sync.Mutex{},
+	}
+}
+
+// resume - continue from a checkpoint saved by a previous sync, its published commits are skipped
+func (s *checkpointState) resume(cp *syncCheckpoint) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.window = cp.Window
+	for _, sha := range cp.Published {
+		s.published[sha] = cp.Window
+	}
+}
+
+// startWindow - start walking commits of a window starting at from
+func (s *checkpointState) startWindow(from time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.window = from
+}
+
+// isPublished - was commit already published by this or by the interrupted sync
+func (s *checkpointState) isPublished(sha string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.published[sha]
+	return ok
+}
+
+// processed - commit was walked in the current window and is waiting to be published
+func (s *checkpointState) processed(sha string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.pending[sha] = s.window
+}
+
+// commitsPublished - commits were published and written to cache
+func (s *checkpointState) commitsPublished(shas []string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, sha := range shas {
+		window, ok := s.pending[sha]
+		if !ok {
+			window = s.window
+		}
+		delete(s.pending, sha)
+		s.published[sha] = window
+	}
+}
+
+// checkpoint - return the current frontier: the oldest window with a commit not published yet
+// or the current window when everything walked so far is published
+func (s *checkpointState) checkpoint() *syncCheckpoint {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.window.IsZero() {
+		return nil
+	}
+	frontier := s.window
+	for _, window := range s.pending {
+		if window.Before(frontier) {
+			frontier = window
+		}
+	}
+	cp := &syncCheckpoint{Window: frontier, Published: []string{}}
+	for sha, window := range s.published {
+		if window.Before(frontier) {
+			// whole window is published, it is never walked again
+			delete(s.published, sha)
+			continue
+		}
+		cp.Published = append(cp.Published, sha)
+	}
+	sort.Strings(cp.Published)
+	return cp
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckpointState(t *testing.T) {
+	w1 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	w2 := w1.Add(24 * time.Hour * 30)
+	w3 := w2.Add(24 * time.Hour * 30)
+	var testCases = []struct {
+		name         string
+		run          func(s *checkpointState)
+		expected     *syncCheckpoint
+		published    []string
+		notPublished []string
+	}{
+		{
+			name:     "nothing walked",
+			run:      func(s *checkpointState) {},
+			expected: nil,
+		},
+		{
+			name: "window started",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+			},
+			expected: &syncCheckpoint{Window: w1, Published: []string{}},
+		},
+		{
+			name: "processed commit is not published",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+				s.processed("a")
+			},
+			expected:     &syncCheckpoint{Window: w1, Published: []string{}},
+			notPublished: []string{"a"},
+		},
+		{
+			name: "frontier stays at the oldest window with a pending commit",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+				s.processed("a")
+				s.startWindow(w2)
+				s.processed("b")
+				s.commitsPublished([]string{"b"})
+				s.startWindow(w3)
+			},
+			expected:     &syncCheckpoint{Window: w1, Published: []string{"b"}},
+			published:    []string{"b"},
+			notPublished: []string{"a"},
+		},
+		{
+			name: "frontier moves once pending commits are published",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+				s.processed("a")
+				s.startWindow(w2)
+				s.processed("b")
+				s.processed("c")
+				s.commitsPublished([]string{"b"})
+				_ = s.checkpoint()
+				s.commitsPublished([]string{"a"})
+			},
+			expected:     &syncCheckpoint{Window: w2, Published: []string{"b"}},
+			published:    []string{"b"},
+			notPublished: []string{"c"},
+		},
+		{
+			name: "published commits of windows before the frontier are pruned",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+				s.processed("a")
+				s.commitsPublished([]string{"a"})
+				s.startWindow(w2)
+				s.processed("b")
+				s.commitsPublished([]string{"b"})
+				s.startWindow(w3)
+			},
+			expected:     &syncCheckpoint{Window: w3, Published: []string{}},
+			notPublished: []string{"a", "b"},
+		},
+		{
+			name: "commit published without being processed belongs to the current window",
+			run: func(s *checkpointState) {
+				s.startWindow(w1)
+				s.startWindow(w2)
+				s.commitsPublished([]string{"x"})
+			},
+			expected:  &syncCheckpoint{Window: w2, Published: []string{"x"}},
+			published: []string{"x"},
+		},
+		{
+			name: "resume from a saved checkpoint",
+			run: func(s *checkpointState) {
+				s.resume(&syncCheckpoint{Window: w2, Published: []string{"b", "a"}})
+			},
+			expected:  &syncCheckpoint{Window: w2, Published: []string{"a", "b"}},
+			published: []string{"a", "b"},
+		},
+		{
+			name: "resumed window walked again",
+			run: func(s *checkpointState) {
+				s.resume(&syncCheckpoint{Window: w2, Published: []string{"a", "b"}})
+				s.startWindow(w2)
+				s.processed("c")
+				s.commitsPublished([]string{"c"})
+			},
+			expected:  &syncCheckpoint{Window: w2, Published: []string{"a", "b", "c"}},
+			published: []string{"a", "b", "c"},
+		},
+		{
+			name: "resumed window fully published",
+			run: func(s *checkpointState) {
+				s.resume(&syncCheckpoint{Window: w2, Published: []string{"a", "b"}})
+				s.startWindow(w2)
+				s.processed("c")
+				s.commitsPublished([]string{"c"})
+				s.startWindow(w3)
+				s.processed("d")
+			},
+			expected:     &syncCheckpoint{Window: w3, Published: []string{}},
+			notPublished: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "resume from checkpoint of an interrupted sync",
+			run: func(s *checkpointState) {
+				prev := newCheckpointState()
+				prev.startWindow(w1)
+				prev.processed("a")
+				prev.commitsPublished([]string{"a"})
+				prev.startWindow(w2)
+				prev.processed("b")
+				prev.processed("c")
+				prev.commitsPublished([]string{"c"})
+				s.resume(prev.checkpoint())
+			},
+			expected:     &syncCheckpoint{Window: w2, Published: []string{"c"}},
+			published:    []string{"c"},
+			notPublished: []string{"a", "b"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newCheckpointState()
+			tc.run(s)
+			got := s.checkpoint()
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+			for _, sha := range tc.published {
+				if !s.isPublished(sha) {
+					t.Errorf("expected %s to be published", sha)
+				}
+			}
+			for _, sha := range tc.notPublished {
+				if s.isPublished(sha) {
+					t.Errorf("expected %s not to be published", sha)
+				}
+			}
+		})
+	}
+}
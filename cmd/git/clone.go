@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GitCloneStrategyFull - full bare clone, all history and all file contents
+	GitCloneStrategyFull = "full"
+	// GitCloneStrategyBlobless - partial clone without file contents (--filter=blob:none)
+	// commits, trees and file names are available, line stats, renames and lines of code are not
+	GitCloneStrategyBlobless = "blobless"
+	// GitCloneStrategyShallowSince - shallow clone of commits newer than date from (--shallow-since)
+	// commits at the shallow boundary have no parent to compare with, so they have no file stats
+	GitCloneStrategyShallowSince = "shallow-since"
+)
+
+// cloneArgs - extra git clone arguments of the configured clone strategy
+// shallow clone needs date from and a previous sync, without them the full clone is made
+func (j *DSGit) cloneArgs(ctx *shared.Ctx) []string {
+	switch j.CloneStrategy {
+	case GitCloneStrategyBlobless:
+		return []string{"--filter=blob:none"}
+	case GitCloneStrategyShallowSince:
+		if ctx.DateFrom == nil || ctx.DateFrom.IsZero() {
+			j.log.WithFields(logrus.Fields{"operation": "cloneArgs"}).Warningf("WARNING: %s clone strategy needs date from, making full clone", j.CloneStrategy)
+			return nil
+		}
+		if j.state == nil || j.state.previousSync == nil {
+			j.log.WithFields(logrus.Fields{"operation": "cloneArgs"}).Warningf("WARNING: %s clone strategy needs commits count and first commit date of a previous sync, making full clone", j.CloneStrategy)
+			return nil
+		}
+		return []string{"--shallow-since=" + ctx.DateFrom.UTC().Format(time.RFC3339)}
+	}
+	return nil
+}
+
+// detectCloneStrategy - return strategy the existing clone was made with, it can differ from the configured one
+// when the clone was made by a previous run or the server did not support the configured strategy
+func detectCloneStrategy(r *goGit.Repository) string {
+	if shallow, err := r.Storer.Shallow(); err == nil && len(shallow) > 0 {
+		return GitCloneStrategyShallowSince
+	}
+	if cfg, err := r.Config(); err == nil && cfg.Raw.Section("remote").Subsection("origin").Option("promisor") == "true" {
+		return GitCloneStrategyBlobless
+	}
+	return GitCloneStrategyFull
+}
+
+// setCloneStrategy - remember strategy of the opened clone in the sync state
+func (j *DSGit) setCloneStrategy(r *goGit.Repository) {
+	j.state.cloneStrategy = detectCloneStrategy(r)
+	if j.state.cloneStrategy != GitCloneStrategyFull {
+		j.log.WithFields(logrus.Fields{"operation": "setCloneStrategy"}).Infof("%s clone, missing objects are skipped", j.state.cloneStrategy)
+	}
+}
+
+// isPartialClone - is the synced clone blobless or shallow
+func (j *DSGit) isPartialClone() bool {
+	return j.state != nil && j.state.cloneStrategy != "" && j.state.cloneStrategy != GitCloneStrategyFull
+}
+
+// isMissingObject - is error caused by an object not present in a partial or shallow clone
+func isMissingObject(err error) bool {
+	return errors.Is(err, plumbing.ErrObjectNotFound)
+}
+
+// cloneFallback - remove failed partial clone, so a full clone can be tried
+func (j *DSGit) cloneFallback(err error) error {
+	j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Warningf("WARNING: %s clone failed: %v, falling back to full clone", j.CloneStrategy, err)
+	return os.RemoveAll(j.GitPath)
+}
+
+// loadPreviousSync - load last sync file of a previous sync, shallow clones take commits count and first commit date from it
+// a shallow clone history ends at its boundary, so they cannot be computed from it
+// the previous sync is unknown when the file cannot be read or has no commits count and first commit date
+func (j *DSGit) loadPreviousSync() {
+	if j.state.previousSync != nil {
+		return
+	}
+	data, err := j.getLastSyncData()
+	if err != nil || data.Target <= 0 || data.FirstCommitAt.IsZero() {
+		return
+	}
+	j.state.previousSync = &data
+}
+
+// checkShallowClone - refuse a shallow clone of a repository without a known previous sync
+// hot repository detection, commits count and first commit date would be computed from truncated history
+func (j *DSGit) checkShallowClone() error {
+	if j.state.cloneStrategy != GitCloneStrategyShallowSince {
+		return nil
+	}
+	j.loadPreviousSync()
+	if j.state.previousSync == nil {
+		return fmt.Errorf("%s is a shallow clone, but no previous sync recorded commits count and first commit date, remove it to make a full clone", j.GitPath)
+	}
+	return nil
+}
+
+// shallowCommitsCount - previous sync commits count plus commits added since its head
+// previous count is used as is when its head is not in the shallow clone
+func (j *DSGit) shallowCommitsCount(ctx *shared.Ctx, prev *lastSyncFile) int {
+	if prev.Head == "" {
+		return prev.Target
+	}
+	cmdLine := []string{"git", "rev-list", "--count", prev.Head + ".." + j.DefaultBranch}
+	sout, serr, err := shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
+	if err != nil {
+		j.log.WithFields(logrus.Fields{"operation": "shallowCommitsCount"}).Warningf("WARNING: cannot count commits since %s: %v: %s", prev.Head, err, serr)
+		return prev.Target
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(sout))
+	if err != nil {
+		return prev.Target
+	}
+	return prev.Target + count
+}
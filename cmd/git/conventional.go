@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// GitConventionalTypes - Conventional Commits types recognized in commit titles (lowercase)
+	// titles with other prefixes, like Linux kernel style "net: fix ...", are not treated as conventional commits
+	GitConventionalTypes = map[string]struct{}{
+		"feat": {}, "fix": {}, "docs": {}, "style": {}, "refactor": {}, "perf": {}, "test": {}, "tests": {},
+		"build": {}, "ci": {}, "chore": {}, "revert": {}, "deps": {}, "security": {}, "release": {},
+	}
+	// GitConventionalTitlePattern - type(scope)!: description
+	GitConventionalTitlePattern = regexp.MustCompile(`^(?P<type>[a-zA-Z]+)(?:\((?P<scope>[^()\r\n]*)\))?(?P<breaking>!)?:[ \t]+(?P<description>\S.*)$`)
+	// GitBreakingChangePattern - BREAKING CHANGE footer, BREAKING-CHANGE is its synonym
+	GitBreakingChangePattern = regexp.MustCompile(`^BREAKING[ \-]CHANGE:[ \t]*(?P<value>.*)$`)
+)
+
+// ConventionalCommit - commit title parsed according to Conventional Commits
+type ConventionalCommit struct {
+	Type            string   `json:"type"`
+	Scope           string   `json:"scope,omitempty"`
+	Breaking        bool     `json:"breaking"`
+	Description     string   `json:"description"`
+	BreakingChanges []string `json:"breaking_changes,omitempty"`
+}
+
+// ParseConventionalCommit - parse commit title, returns nil when it is not a conventional commit
+// breakingChanges are BREAKING CHANGE footer values, any of them marks the commit as breaking
+func ParseConventionalCommit(title string, breakingChanges []string) *ConventionalCommit {
+	m := GitConventionalTitlePattern.FindStringSubmatch(strings.TrimSpace(title))
+	if m == nil {
+		return nil
+	}
+	groups := make(map[string]string)
+	for i, name := range GitConventionalTitlePattern.SubexpNames() {
+		if name != "" {
+			groups[name] = m[i]
+		}
+	}
+	typ := strings.ToLower(groups["type"])
+	if _, ok := GitConventionalTypes[typ]; !ok {
+		return nil
+	}
+	return &ConventionalCommit{
+		Type:            typ,
+		Scope:           strings.TrimSpace(groups["scope"]),
+		Breaking:        groups["breaking"] != "" || len(breakingChanges) > 0,
+		Description:     strings.TrimSpace(groups["description"]),
+		BreakingChanges: breakingChanges,
+	}
+}
+
+// ParseBreakingChange - store BREAKING CHANGE footer in commit, returns true if line was such footer
+func ParseBreakingChange(commit map[string]interface{}, line string) bool {
+	m := GitBreakingChangePattern.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	value := strings.TrimSpace(m[1])
+	if value == "" {
+		return true
+	}
+	ary, _ := commit["breaking_changes"].([]interface{})
+	commit["breaking_changes"] = append(ary, value)
+	return true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	var testCases = []struct {
+		name            string
+		title           string
+		breakingChanges []string
+		expected        *ConventionalCommit
+	}{
+		{name: "type", title: "feat: add tags sync", expected: &ConventionalCommit{Type: "feat", Description: "add tags sync"}},
+		{name: "type and scope", title: "fix(api): handle nil body", expected: &ConventionalCommit{Type: "fix", Scope: "api", Description: "handle nil body"}},
+		{name: "upper case type", title: "Docs: update README", expected: &ConventionalCommit{Type: "docs", Description: "update README"}},
+		{name: "empty scope", title: "chore(): bump deps", expected: &ConventionalCommit{Type: "chore", Description: "bump deps"}},
+		{name: "scope with spaces and slashes", title: "build( ci/docker ): pin base image", expected: &ConventionalCommit{Type: "build", Scope: "ci/docker", Description: "pin base image"}},
+		{name: "breaking", title: "feat!: drop v1 API", expected: &ConventionalCommit{Type: "feat", Breaking: true, Description: "drop v1 API"}},
+		{name: "breaking with scope", title: "refactor(core)!: rename config keys", expected: &ConventionalCommit{Type: "refactor", Scope: "core", Breaking: true, Description: "rename config keys"}},
+		{
+			name:            "breaking change footer",
+			title:           "feat(cli): new flags",
+			breakingChanges: []string{"--url is required"},
+			expected:        &ConventionalCommit{Type: "feat", Scope: "cli", Breaking: true, Description: "new flags", BreakingChanges: []string{"--url is required"}},
+		},
+		{name: "surrounding whitespace", title: "  perf: faster walk  ", expected: &ConventionalCommit{Type: "perf", Description: "faster walk"}},
+		{name: "unknown type", title: "net: fix ethtool stats"},
+		{name: "subsystem style title", title: "drm/i915: fix hang"},
+		{name: "missing space after colon", title: "feat:add tags"},
+		{name: "missing description", title: "fix: "},
+		{name: "space before colon", title: "fix : typo"},
+		{name: "nested parentheses in scope", title: "fix(a(b)): typo"},
+		{name: "plain title", title: "Update README"},
+		{name: "merge commit", title: "Merge pull request #1 from org/fix: typo"},
+		{name: "empty", title: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseConventionalCommit(tc.title, tc.breakingChanges)
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseBreakingChange(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		lines    []string
+		footer   []bool
+		expected []interface{}
+	}{
+		{name: "footer", lines: []string{"BREAKING CHANGE: config moved"}, footer: []bool{true}, expected: []interface{}{"config moved"}},
+		{name: "hyphenated synonym", lines: []string{"BREAKING-CHANGE: flags renamed"}, footer: []bool{true}, expected: []interface{}{"flags renamed"}},
+		{
+			name:     "several footers",
+			lines:    []string{"BREAKING CHANGE: a", "Signed-off-by: Jane Doe <jane@example.com>", "BREAKING CHANGE:  b "},
+			footer:   []bool{true, false, true},
+			expected: []interface{}{"a", "b"},
+		},
+		{name: "empty value", lines: []string{"BREAKING CHANGE:"}, footer: []bool{true}},
+		{name: "lower case is not a footer", lines: []string{"breaking change: x"}, footer: []bool{false}},
+		{name: "not at line start", lines: []string{"Note BREAKING CHANGE: x"}, footer: []bool{false}},
+		{name: "missing colon", lines: []string{"BREAKING CHANGE x"}, footer: []bool{false}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commit := make(map[string]interface{})
+			for i, line := range tc.lines {
+				if got := ParseBreakingChange(commit, line); got != tc.footer[i] {
+					t.Errorf("expected %v for %q, got %v", tc.footer[i], line, got)
+				}
+			}
+			got, _ := commit["breaking_changes"].([]interface{})
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+// CommitDCO - Developer Certificate of Origin check: commit has a Signed-off-by trailer matching its author
+// Merge commits and commits authored by bots are exempt, they are reported as compliant
+type CommitDCO struct {
+	SignedOff bool `json:"signed_off"`
+	Compliant bool `json:"compliant"`
+	Exempt    bool `json:"exempt,omitempty"`
+}
+
+// dcoSummary - non-compliant commits counted during a single sync
+type dcoSummary struct {
+	commits      int64
+	nonCompliant int64
+	authors      map[string]int64
+}
+
+// CheckDCO - check if any Signed-off-by trailer matches commit's author name or email,
+// both author and signers are canonicalized with mailmap first
+// returns canonical author as "Name <email>"
+func (j *DSGit) CheckDCO(ctx *shared.Ctx, commit map[string]interface{}) (dco CommitDCO, author string) {
+	// In pair programming mode Author can be replaced by the first of co-authors
+	author, ok := commit["Author-Original"].(string)
+	if !ok {
+		author, _ = commit["Author"].(string)
+	}
+	author, _ = j.canonicalGitAuthor(author)
+	authorIdent := j.IdentityFromGitAuthor(ctx, author)
+	parents, _ := commit["parents"].([]string)
+	signers := commitTrailer(commit, "Signed-off-by")
+	dco.SignedOff = len(signers) > 0
+	if len(parents) > 1 || j.botClassifier.IsBot(authorIdent[0], authorIdent[2]) {
+		dco.Exempt = true
+		dco.Compliant = true
+		return
+	}
+	for _, iSigner := range signers {
+		signer, _ := iSigner.(string)
+		signer, _ = j.canonicalGitAuthor(signer)
+		signerIdent := j.IdentityFromGitAuthor(ctx, signer)
+		if signerIdent[2] != "" && strings.EqualFold(signerIdent[2], authorIdent[2]) {
+			dco.Compliant = true
+			return
+		}
+		if signerIdent[0] != "" && strings.EqualFold(signerIdent[0], authorIdent[0]) {
+			dco.Compliant = true
+			return
+		}
+	}
+	return
+}
+
+// recordDCO - count commit in the current sync's DCO summary
+func (j *DSGit) recordDCO(dco CommitDCO, author string) {
+	j.state.dcoMtx.Lock()
+	defer j.state.dcoMtx.Unlock()
+	j.state.dco.commits++
+	if dco.Compliant {
+		return
+	}
+	j.state.dco.nonCompliant++
+	j.state.dco.authors[author]++
+}
+
+// ReportDCO - write end-of-sync report with non-compliant commits by author
+func (j *DSGit) ReportDCO(ctx *shared.Ctx) (err error) {
+	if j.reportProvider == nil {
+		return
+	}
+	rData := new(ReportData)
+	j.state.dcoMtx.Lock()
+	rData.DCOCommits = j.state.dco.commits
+	rData.DCONonCompliantCommits = j.state.dco.nonCompliant
+	rData.DCONonCompliantAuthors = make(map[string]int64, len(j.state.dco.authors))
+	for author, n := range j.state.dco.authors {
+		rData.DCONonCompliantAuthors[author] = n
+	}
+	j.state.dcoMtx.Unlock()
+	if rData.DCOCommits == 0 {
+		return
+	}
+	rData.URL = j.URL
+	rData.Date = time.Now().UnixNano()
+	rData.ProjectName = ctx.Project
+	b, err := jsoniter.Marshal(rData)
+	if err != nil {
+		return
+	}
+	err = j.reportProvider.UpdateFileByKey(fmt.Sprintf("%+v-dco-%+v.json", j.endpoint, time.Now().Unix()), b)
+	if err != nil {
+		return
+	}
+	j.log.WithFields(logrus.Fields{"operation": "ReportDCO"}).Infof("DCO: %d/%d commits not compliant, %d authors", rData.DCONonCompliantCommits, rData.DCOCommits, len(rData.DCONonCompliantAuthors))
+	return
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+)
+
+func TestCheckDCO(t *testing.T) {
+	var testCases = []struct {
+		name           string
+		commit         map[string]interface{}
+		expected       CommitDCO
+		expectedAuthor string
+	}{
+		{
+			name:           "signer matches author",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "Signed-off-by": []interface{}{"Jane Doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "signer email matches author with other name",
+			commit:         map[string]interface{}{"Author": "jdoe <Jane@Example.com>", "Signed-off-by": []interface{}{"Jane Doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "jdoe <Jane@Example.com>",
+		},
+		{
+			name:           "signer name matches author with other email",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@work.example.com>", "Signed-off-by": []interface{}{"jane doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@work.example.com>",
+		},
+		{
+			name:           "one of several signers matches author",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "Signed-off-by": []interface{}{"John Roe <john@example.com>", "Jane Doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "signer matches author via mailmap",
+			commit:         map[string]interface{}{"Author": "jdoe <jdoe@old.example.com>", "Signed-off-by": []interface{}{"Jane Doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "sign-off trailer from message trailers",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "message_trailers": map[string]interface{}{"Signed-off-by": []interface{}{"Jane Doe <jane@example.com>"}}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "original author of pair programming commit",
+			commit:         map[string]interface{}{"Author": "John Roe <john@example.com>", "Author-Original": "Jane Doe <jane@example.com>", "Signed-off-by": []interface{}{"Jane Doe <jane@example.com>"}},
+			expected:       CommitDCO{SignedOff: true, Compliant: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "signer is someone else",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "Signed-off-by": []interface{}{"John Roe <john@example.com>"}},
+			expected:       CommitDCO{SignedOff: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "no sign-off",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>"},
+			expected:       CommitDCO{},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "signer without name or valid email",
+			commit:         map[string]interface{}{"Author": "<jane>", "Signed-off-by": []interface{}{"<jane>"}},
+			expected:       CommitDCO{SignedOff: true},
+			expectedAuthor: "<jane>",
+		},
+		{
+			name:           "merge commit is exempt",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "parents": []string{"a", "b"}},
+			expected:       CommitDCO{Compliant: true, Exempt: true},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+		{
+			name:           "bot commit is exempt",
+			commit:         map[string]interface{}{"Author": "dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>", "parents": []string{"a"}},
+			expected:       CommitDCO{Compliant: true, Exempt: true},
+			expectedAuthor: "dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>",
+		},
+		{
+			name:           "commit with single parent is not exempt",
+			commit:         map[string]interface{}{"Author": "Jane Doe <jane@example.com>", "parents": []string{"a"}},
+			expected:       CommitDCO{},
+			expectedAuthor: "Jane Doe <jane@example.com>",
+		},
+	}
+	bots, err := NewBotClassifier("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	j := &DSGit{state: newSyncState(), botClassifier: bots}
+	j.state.mailmap = NewMailmap()
+	j.state.mailmap.Parse([]byte("Jane Doe <jane@example.com> <jdoe@old.example.com>\n"))
+	ctx := &shared.Ctx{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dco, author := j.CheckDCO(ctx, tc.commit)
+			if dco != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, dco)
+			}
+			if author != tc.expectedAuthor {
+				t.Errorf("expected author %s, got %s", tc.expectedAuthor, author)
+			}
+		})
+	}
+}
+
+func TestRecordDCO(t *testing.T) {
+	j := &DSGit{state: newSyncState()}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			j.recordDCO(CommitDCO{SignedOff: true, Compliant: i%2 == 0}, "Jane Doe <jane@example.com>")
+			j.recordDCO(CommitDCO{Compliant: true, Exempt: true}, "John Roe <john@example.com>")
+		}(i)
+	}
+	wg.Wait()
+	expected := dcoSummary{commits: 20, nonCompliant: 5, authors: map[string]int64{"Jane Doe <jane@example.com>": 5}}
+	if !reflect.DeepEqual(expected, j.state.dco) {
+		t.Errorf("expected %+v, got %+v", expected, j.state.dco)
+	}
+}
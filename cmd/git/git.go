@@ -14,6 +14,7 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
@@ -43,6 +44,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	gitCache "github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/storage/filesystem"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/sirupsen/logrus"
@@ -53,16 +55,11 @@ const (
 	GitBackendVersion = "0.1.1"
 	// GitDefaultReposPath - default path where git repository clones
 	GitDefaultReposPath = "/tmp/git-repositories"
-	// GitDefaultCachePath - default path where gitops cache files are stored
+	// GitDefaultCachePath - default path where local cache files are stored
 	GitDefaultCachePath = "/tmp/git-cache"
-	// GitOpsCommand - command that maintains git stats cache
-	// GitOpsCommand = "gitops.py"
-	GitOpsCommand = "gitops"
-	// GitOpsFailureFatal - is GitOpsCommand failure fatal?
+	// GitOpsFailureFatal - is lines of code counting failure fatal?
 	GitOpsFailureFatal = true
-	// OrphanedCommitsCommand - command to list orphaned commits
-	OrphanedCommitsCommand = "detect-removed-commits.sh"
-	// OrphanedCommitsFailureFatal - is OrphanedCommitsCommand failure fatal?
+	// OrphanedCommitsFailureFatal - is orphaned commits detection failure fatal?
 	OrphanedCommitsFailureFatal = true
 	// GitParseStateInit - init parser state
 	GitParseStateInit = 0
@@ -102,6 +99,8 @@ const (
 	Failed = "failed"
 	// Success status
 	Success = "success"
+	// Cancelled status - sync stopped by a signal after flushing processed commits
+	Cancelled = "cancelled"
 	// GitConnector ...
 	GitConnector   = "git-connector"
 	PackSize       = 1000
@@ -525,20 +524,11 @@ var (
 	}
 	// GitTrailerPPAuthors - trailer name to authors map (for pair programming)
 	GitTrailerPPAuthors = map[string]string{"Signed-off-by": "authors_signed_off", "Co-authored-by": "co_authors"}
-	// max upstream date
-	gMaxUpstreamDt             time.Time
-	gMaxUpstreamDtMtx          = &sync.Mutex{}
-	cachedCommits              = make(map[string]CommitCache)
+	// commits cache file names
 	commitsCacheFile           = "commits-cache.csv"
-	createdCommits             = make(map[string]bool)
-	IsHotRep                   = false
 	CommitsByYearCacheFile     = "commits-cache-%s.csv"
 	CommitsUpdateCacheFile     = "commits-update-cache.csv"
-	CurrentCacheYear           = 1970
-	CachedCommitsUpdates       = make(map[string]CommitCache)
 	CommitsByYearHalfCacheFile = "commits-cache-%s-%s.csv"
-	CurrentCacheYearHalf       = YearFirstHalf
-	FirstCommitAt              time.Time
 )
 
 // Publisher - for streaming data to Kinesis
@@ -546,15 +536,6 @@ type Publisher interface {
 	PushEvents(action, source, eventType, subEventType, env string, data []interface{}, endpoint string) (string, error)
 }
 
-// RawPLS - programming language summary (all fields as strings)
-type RawPLS struct {
-	Language string `json:"language"`
-	Files    string `json:"files"`
-	Blank    string `json:"blank"`
-	Comment  string `json:"comment"`
-	Code     string `json:"code"`
-}
-
 // PLS - programming language summary
 type PLS struct {
 	Language string `json:"language"`
@@ -566,22 +547,70 @@ type PLS struct {
 
 // DSGit - DS implementation for git
 type DSGit struct {
-	URL              string // git repo URL, for example https://github.com/cncf/devstats
-	ReposPath        string // path to store git repo clones, defaults to /tmp/git-repositories
-	CachePath        string // path to store gitops results cache, defaults to /tmp/git-cache
-	SkipCacheCleanup bool   // skip gitops cache cleanup
+	URL               string // git repo URL, for example https://github.com/cncf/devstats, file:// URL, local repository or bundle
+	OriginURL         string // optional logical origin URL used for repository URL, ID and commit links when URL is local or a mirror
+	ReposPath         string // path to store git repo clones, defaults to /tmp/git-repositories
+	CachePath         string // path to store local commits cache, defaults to /tmp/git-cache
+	SkipCacheCleanup  bool   // deprecated, no-op since gitops was replaced by in-process lines counting
+	LocalOutput       string // if set, publish events as NDJSON files into this directory instead of the data lake
+	LocalMaxEvents    int    // maximum number of events in a single local NDJSON file
+	CacheBackend      string // commits cache backend: s3 (default), local (stored in CachePath) or memory
+	Manifest          string // YAML/JSON manifest listing repositories to sync in a single run
+	ManifestWorkers   int    // number of manifest repositories synced in parallel
+	ManifestSummary   string // optional path where manifest sync summary is written as JSON
+	AllBranches       bool   // walk commits from all branches instead of HEAD only
+	TagEvents         bool   // emit tag.created/tag.updated events for repository tags
+	StatsSnapshots    bool   // emit monthly repository stats (lines of code, languages) snapshot events
+	StatsMonths       int    // number of most recent months with repository stats snapshots, 0 means the whole history
+	Mailmap           string // optional operator supplied mailmap file, applied on top of repository's .mailmap
+	Trailers          string // optional YAML/JSON trailer mapping file merged over built-in trailer maps
+	TrailerAuthors    bool   // add trailer-derived authors and committers (Co-authored-by, Signed-off-by, ...) of go-git walked commits
+	BotPatterns       string // optional YAML/JSON bot patterns file (names, emails, exclude) added to built-in patterns
+	GPGKeyring        string // optional armored or binary GPG public keyring used to verify GPG signed commits
+	SSHAllowedSigners string // optional SSH allowed signers file used to verify SSH signed commits
+	Username          string // optional HTTPS basic auth username, token or password is read from GIT_TOKEN/GIT_PASSWORD env or PasswordFile
+	PasswordFile      string // optional file with HTTPS password or token
+	SSHKey            string // optional SSH private key file used for ssh:// and scp-like URLs
+	SSHKnownHosts     string // optional SSH known_hosts file, host keys are checked strictly when set
+	Netrc             string // optional netrc file with HTTPS credentials, used when no password or token is set
+	CredentialsHost   string // optional host HTTPS username and token or password are sent to, required with them in manifest mode
+	CloneStrategy     string // clone strategy: full (default), blobless (--filter=blob:none) or shallow-since (--shallow-since=date from)
 	// Flags
-	FlagURL              *string
-	FlagReposPath        *string
-	FlagCachePath        *string
-	FlagSkipCacheCleanup *bool
-	FlagStream           *string
-	FlagSourceID         *string
-	FlagRepositorySource *string
+	FlagURL               *string
+	FlagOriginURL         *string
+	FlagReposPath         *string
+	FlagCachePath         *string
+	FlagSkipCacheCleanup  *bool
+	FlagStream            *string
+	FlagSourceID          *string
+	FlagRepositorySource  *string
+	FlagLocalOutput       *string
+	FlagLocalMaxEvents    *int
+	FlagCacheBackend      *string
+	FlagManifest          *string
+	FlagManifestWorkers   *int
+	FlagManifestSummary   *string
+	FlagAllBranches       *bool
+	FlagTagEvents         *bool
+	FlagStatsSnapshots    *bool
+	FlagStatsMonths       *int
+	FlagMailmap           *string
+	FlagTrailers          *string
+	FlagTrailerAuthors    *bool
+	FlagBotPatterns       *string
+	FlagGPGKeyring        *string
+	FlagSSHAllowedSigners *string
+	FlagUsername          *string
+	FlagPasswordFile      *string
+	FlagSSHKey            *string
+	FlagSSHKnownHosts     *string
+	FlagNetrc             *string
+	FlagCredentialsHost   *string
+	FlagCloneStrategy     *string
 	// Non-config variables
 	RepoName        string // repo name
-	Loc             int    // lines of code as reported by GitOpsCommand
-	Pls             []PLS  // programming language suppary as reported by GitOpsCommand
+	Loc             int    // lines of code at HEAD as counted by GetGitOps
+	Pls             []PLS  // programming language summary at HEAD as counted by GetGitOps
 	StatsDt         time.Time
 	GitPath         string                            // path to git repo clone
 	LineScanner     *bufio.Scanner                    // line scanner for git log
@@ -608,14 +637,57 @@ type DSGit struct {
 	// converted to a string such as 194341141. For gerrit this is the project (repository) slug.
 	SourceID string
 	// RepositorySource for example git, github or gerrit
-	RepositorySource string
-	log              *logrus.Entry
-	cacheProvider    cache.Manager
-	endpoint         string
-	reportProvider   *report.Manager
-	auth0Client      *auth0.ClientProvider
-	headCommitHash   string
-	headLinesOfCode  int
+	RepositorySource  string
+	log               *logrus.Entry
+	cacheProvider     CacheProvider
+	endpoint          string
+	reportProvider    *report.Manager
+	auth0Client       *auth0.ClientProvider
+	headCommitHash    string
+	headLinesOfCode   int
+	state             *syncState
+	trailersConfig    *TrailersConfig    // trailer mapping file, resolved per project at the start of each sync
+	botClassifier     *BotClassifier     // classifies contributors as bots, built-in patterns plus bot patterns file
+	signatureVerifier *SignatureVerifier // verifies commit signatures against configured GPG keyring and SSH allowed signers
+	credentials       *gitCredentials    // clone and fetch credentials, pointer so they are not printed with configuration
+	cloneSource       string             // URL or local path the repository is cloned from when OriginURL replaces URL
+}
+
+// syncState - mutable state of a single repository sync, each sync starts with a fresh one
+type syncState struct {
+	cachedCommits        map[string]CommitCache // commits cache keyed by content hash
+	createdCommits       map[string]bool        // IDs of commits already published
+	cachedCommitsUpdates map[string]CommitCache // hot repository updated commits cache
+	isHotRepo            bool                   // repository has at least HotRepoCount commits, cache is split by year halves
+	currentCacheYear     int                    // hot repository year of the currently used cache file
+	currentCacheYearHalf string                 // hot repository year half of the currently used cache file
+	firstCommitAt        time.Time              // first commit author date
+	maxUpstreamDt        time.Time              // max published commit author date
+	maxUpstreamDtMtx     *sync.Mutex
+	mailmap              *Mailmap        // repository .mailmap merged with operator supplied mailmap
+	trailers             *trailerMapping // trailer mapping resolved for the synced project
+	dco                  dcoSummary      // DCO non-compliant commits by author
+	dcoMtx               *sync.Mutex
+	cloneStrategy        string                // strategy the synced clone was made with, detected from the clone
+	checkpoint           *checkpointState      // published commits frontier saved with last sync
+	previousSync         *lastSyncFile         // last sync file with commits count and first commit date, loaded for shallow clones
+	headLoc              map[string]clocResult // lines of code of headLocSHA, counted once for cloc_count and gitops
+	headLocSHA           string
+}
+
+// newSyncState - return empty sync state
+func newSyncState() *syncState {
+	return &syncState{
+		cachedCommits:        make(map[string]CommitCache),
+		createdCommits:       make(map[string]bool),
+		cachedCommitsUpdates: make(map[string]CommitCache),
+		currentCacheYear:     1970,
+		currentCacheYearHalf: YearFirstHalf,
+		maxUpstreamDtMtx:     &sync.Mutex{},
+		dco:                  dcoSummary{authors: make(map[string]int64)},
+		dcoMtx:               &sync.Mutex{},
+		checkpoint:           newCheckpointState(),
+	}
 }
 
 // PublisherPushEvents - this is a fake function to test publisher locally
@@ -662,7 +734,7 @@ func (j *DSGit) WriteLog(ctx *shared.Ctx, timestamp time.Time, status, message s
 		TaskARN:   arn,
 		Configuration: []map[string]string{
 			{
-				"REPO_URL":    j.URL,
+				"REPO_URL":    RedactURL(j.URL),
 				"repo_slug":   j.endpoint,
 				"ProjectSlug": ctx.Project,
 			}},
@@ -675,13 +747,37 @@ func (j *DSGit) WriteLog(ctx *shared.Ctx, timestamp time.Time, status, message s
 
 // AddFlags - add git specific flags
 func (j *DSGit) AddFlags() {
-	j.FlagURL = flag.String("git-url", "", "git repo URL, for example https://github.com/cncf/devstats")
+	j.FlagURL = flag.String("git-url", "", "git repo URL, for example https://github.com/cncf/devstats, also a file:// URL, a local (bare) repository directory or a .bundle file")
+	j.FlagOriginURL = flag.String("git-origin-url", "", "logical origin URL used for repository URL, repository ID and commit links, for example when git-url is a local mirror or bundle")
 	j.FlagReposPath = flag.String("git-repos-path", GitDefaultReposPath, "path to store git repo clones, defaults to "+GitDefaultReposPath)
-	j.FlagCachePath = flag.String("git-cache-path", GitDefaultCachePath, "path to store gitops results cache, defaults to"+GitDefaultCachePath)
-	j.FlagSkipCacheCleanup = flag.Bool("git-skip-cache-cleanup", false, "skip gitops cache cleanup")
+	j.FlagCachePath = flag.String("git-cache-path", GitDefaultCachePath, "path to store local commits cache, defaults to "+GitDefaultCachePath)
+	j.FlagSkipCacheCleanup = flag.Bool("git-skip-cache-cleanup", false, "deprecated, no-op")
 	j.FlagStream = flag.String("git-stream", GitDefaultStream, "git kinesis stream name, for example PUT-S3-git-commits")
 	j.FlagSourceID = flag.String("git-source-id", "", "repository source id")
 	j.FlagRepositorySource = flag.String("git-repository-source", "", "repository source for example git, github or gerrit")
+	j.FlagLocalOutput = flag.String("git-local-output", "", "if set, write commit events as NDJSON files into this directory instead of publishing them to the data lake")
+	j.FlagLocalMaxEvents = flag.Int("git-local-max-events", LocalPublisherDefaultMaxEvents, "maximum number of events stored in a single local NDJSON file")
+	j.FlagCacheBackend = flag.String("git-cache-backend", CacheBackendS3, "commits cache backend: s3, local (files stored in git-cache-path) or memory")
+	j.FlagManifest = flag.String("git-manifest", "", "YAML/JSON manifest with a list of repositories to sync (url, repository_source, source_id, project, date_from, date_to), used instead of git-url")
+	j.FlagManifestWorkers = flag.Int("git-manifest-workers", GitDefaultManifestWorkers, "number of manifest repositories synced in parallel")
+	j.FlagManifestSummary = flag.String("git-manifest-summary", "", "path to write manifest sync summary JSON to")
+	j.FlagAllBranches = flag.Bool("git-all-branches", false, "walk commits reachable from all branches, not only from HEAD")
+	j.FlagTagEvents = flag.Bool("git-tag-events", false, "emit tag.created/tag.updated events for lightweight and annotated tags")
+	j.FlagTrailers = flag.String("git-trailers", "", "optional YAML/JSON trailer mapping file (allowed_trailers, other_authors, same_as_author, per-project overrides in projects) merged over built-in defaults")
+	j.FlagTrailerAuthors = flag.Bool("git-trailer-authors", false, "add authors and committers from message trailers (Co-authored-by, Signed-off-by, ...) to commits walked from the repository clone")
+	j.FlagBotPatterns = flag.String("git-bot-patterns", "", "optional YAML/JSON file with bot name/email regexps (names, emails) and never-bot identities (exclude) added to built-in bot patterns")
+	j.FlagGPGKeyring = flag.String("git-gpg-keyring", "", "optional armored or binary GPG public keyring, when set GPG signed commits are verified")
+	j.FlagSSHAllowedSigners = flag.String("git-ssh-allowed-signers", "", "optional SSH allowed signers file (see ssh-keygen ALLOWED SIGNERS), when set SSH signed commits are verified")
+	j.FlagUsername = flag.String("git-username", "", "HTTPS username, password or token is read from GIT_TOKEN/GIT_PASSWORD env or git-password-file")
+	j.FlagPasswordFile = flag.String("git-password-file", "", "file with HTTPS password or token")
+	j.FlagSSHKey = flag.String("git-ssh-key", "", "SSH private key file used to clone and fetch ssh:// and git@host:org/repo URLs")
+	j.FlagSSHKnownHosts = flag.String("git-ssh-known-hosts", "", "SSH known_hosts file, when set host keys are checked strictly against it")
+	j.FlagNetrc = flag.String("git-netrc", "", "netrc file with HTTPS credentials, used when no password or token is configured")
+	j.FlagCredentialsHost = flag.String("git-credentials-host", "", "host HTTPS username and token or password are sent to, other hosts get no credentials, required with them in manifest mode")
+	j.FlagCloneStrategy = flag.String("git-clone-strategy", GitCloneStrategyFull, "clone strategy: full, blobless (partial clone without file contents, no line stats and lines of code) or shallow-since (only commits since date from)")
+	j.FlagMailmap = flag.String("git-mailmap", "", "optional mailmap file applied on top of repository's .mailmap, its entries take precedence")
+	j.FlagStatsSnapshots = flag.Bool("git-stats-snapshots", false, "emit repository stats events with lines of code and languages at the last commit of each month")
+	j.FlagStatsMonths = flag.Int("git-stats-months", GitDefaultStatsMonths, "number of most recent months with repository stats snapshots, 0 means the whole history")
 }
 
 // ParseArgs - parse git specific environment variables
@@ -694,6 +790,14 @@ func (j *DSGit) ParseArgs(ctx *shared.Ctx) (err error) {
 		j.URL = ctx.Env("URL")
 	}
 
+	// git logical origin URL
+	if shared.FlagPassed(ctx, "origin-url") {
+		j.OriginURL = strings.TrimSpace(*j.FlagOriginURL)
+	}
+	if ctx.EnvSet("ORIGIN_URL") {
+		j.OriginURL = ctx.Env("ORIGIN_URL")
+	}
+
 	// git repos path
 	j.ReposPath = GitDefaultReposPath
 	if shared.FlagPassed(ctx, "repos-path") && *j.FlagReposPath != "" {
@@ -730,6 +834,201 @@ func (j *DSGit) ParseArgs(ctx *shared.Ctx) (err error) {
 		j.Stream = ctx.Env("STREAM")
 	}
 
+	// git local NDJSON output
+	if shared.FlagPassed(ctx, "local-output") {
+		j.LocalOutput = strings.TrimSpace(*j.FlagLocalOutput)
+	}
+	if ctx.EnvSet("LOCAL_OUTPUT") {
+		j.LocalOutput = ctx.Env("LOCAL_OUTPUT")
+	}
+	j.LocalMaxEvents = LocalPublisherDefaultMaxEvents
+	if shared.FlagPassed(ctx, "local-max-events") && *j.FlagLocalMaxEvents > 0 {
+		j.LocalMaxEvents = *j.FlagLocalMaxEvents
+	}
+	if ctx.EnvSet("LOCAL_MAX_EVENTS") {
+		maxEvents, e := strconv.Atoi(ctx.Env("LOCAL_MAX_EVENTS"))
+		if e != nil || maxEvents <= 0 {
+			err = fmt.Errorf("invalid LOCAL_MAX_EVENTS value: %s", ctx.Env("LOCAL_MAX_EVENTS"))
+			return
+		}
+		j.LocalMaxEvents = maxEvents
+	}
+
+	// git commits cache backend
+	j.CacheBackend = CacheBackendS3
+	if shared.FlagPassed(ctx, "cache-backend") && *j.FlagCacheBackend != "" {
+		j.CacheBackend = strings.TrimSpace(*j.FlagCacheBackend)
+	}
+	if ctx.EnvSet("CACHE_BACKEND") {
+		j.CacheBackend = ctx.Env("CACHE_BACKEND")
+	}
+
+	// git clone strategy
+	j.CloneStrategy = GitCloneStrategyFull
+	if shared.FlagPassed(ctx, "clone-strategy") && *j.FlagCloneStrategy != "" {
+		j.CloneStrategy = strings.TrimSpace(*j.FlagCloneStrategy)
+	}
+	if ctx.EnvSet("CLONE_STRATEGY") {
+		j.CloneStrategy = ctx.Env("CLONE_STRATEGY")
+	}
+
+	// git manifest (batch mode)
+	if shared.FlagPassed(ctx, "manifest") {
+		j.Manifest = strings.TrimSpace(*j.FlagManifest)
+	}
+	if ctx.EnvSet("MANIFEST") {
+		j.Manifest = ctx.Env("MANIFEST")
+	}
+	j.ManifestWorkers = GitDefaultManifestWorkers
+	if shared.FlagPassed(ctx, "manifest-workers") && *j.FlagManifestWorkers > 0 {
+		j.ManifestWorkers = *j.FlagManifestWorkers
+	}
+	if ctx.EnvSet("MANIFEST_WORKERS") {
+		workers, e := strconv.Atoi(ctx.Env("MANIFEST_WORKERS"))
+		if e != nil || workers <= 0 {
+			err = fmt.Errorf("invalid MANIFEST_WORKERS value: %s", ctx.Env("MANIFEST_WORKERS"))
+			return
+		}
+		j.ManifestWorkers = workers
+	}
+	if shared.FlagPassed(ctx, "manifest-summary") {
+		j.ManifestSummary = strings.TrimSpace(*j.FlagManifestSummary)
+	}
+	if ctx.EnvSet("MANIFEST_SUMMARY") {
+		j.ManifestSummary = ctx.Env("MANIFEST_SUMMARY")
+	}
+
+	// git all branches mode
+	if shared.FlagPassed(ctx, "all-branches") {
+		j.AllBranches = *j.FlagAllBranches
+	}
+	allBranches, present := ctx.BoolEnvSet("ALL_BRANCHES")
+	if present {
+		j.AllBranches = allBranches
+	}
+
+	// git tag events
+	if shared.FlagPassed(ctx, "tag-events") {
+		j.TagEvents = *j.FlagTagEvents
+	}
+	tagEvents, present := ctx.BoolEnvSet("TAG_EVENTS")
+	if present {
+		j.TagEvents = tagEvents
+	}
+
+	// git repository stats snapshots
+	if shared.FlagPassed(ctx, "stats-snapshots") {
+		j.StatsSnapshots = *j.FlagStatsSnapshots
+	}
+	statsSnapshots, present := ctx.BoolEnvSet("STATS_SNAPSHOTS")
+	if present {
+		j.StatsSnapshots = statsSnapshots
+	}
+	j.StatsMonths = GitDefaultStatsMonths
+	if shared.FlagPassed(ctx, "stats-months") && *j.FlagStatsMonths >= 0 {
+		j.StatsMonths = *j.FlagStatsMonths
+	}
+	if ctx.EnvSet("STATS_MONTHS") {
+		months, e := strconv.Atoi(ctx.Env("STATS_MONTHS"))
+		if e != nil || months < 0 {
+			err = fmt.Errorf("invalid STATS_MONTHS value: %s", ctx.Env("STATS_MONTHS"))
+			return
+		}
+		j.StatsMonths = months
+	}
+
+	// git operator mailmap
+	if shared.FlagPassed(ctx, "mailmap") {
+		j.Mailmap = strings.TrimSpace(*j.FlagMailmap)
+	}
+	if ctx.EnvSet("MAILMAP") {
+		j.Mailmap = ctx.Env("MAILMAP")
+	}
+
+	// git trailer mapping
+	if shared.FlagPassed(ctx, "trailers") {
+		j.Trailers = strings.TrimSpace(*j.FlagTrailers)
+	}
+	if ctx.EnvSet("TRAILERS") {
+		j.Trailers = ctx.Env("TRAILERS")
+	}
+
+	// git trailer authors
+	if shared.FlagPassed(ctx, "trailer-authors") {
+		j.TrailerAuthors = *j.FlagTrailerAuthors
+	}
+	trailerAuthors, present := ctx.BoolEnvSet("TRAILER_AUTHORS")
+	if present {
+		j.TrailerAuthors = trailerAuthors
+	}
+
+	// git bot patterns
+	if shared.FlagPassed(ctx, "bot-patterns") {
+		j.BotPatterns = strings.TrimSpace(*j.FlagBotPatterns)
+	}
+	if ctx.EnvSet("BOT_PATTERNS") {
+		j.BotPatterns = ctx.Env("BOT_PATTERNS")
+	}
+
+	// git commit signatures verification
+	if shared.FlagPassed(ctx, "gpg-keyring") {
+		j.GPGKeyring = strings.TrimSpace(*j.FlagGPGKeyring)
+	}
+	if ctx.EnvSet("GPG_KEYRING") {
+		j.GPGKeyring = ctx.Env("GPG_KEYRING")
+	}
+	if shared.FlagPassed(ctx, "ssh-allowed-signers") {
+		j.SSHAllowedSigners = strings.TrimSpace(*j.FlagSSHAllowedSigners)
+	}
+	if ctx.EnvSet("SSH_ALLOWED_SIGNERS") {
+		j.SSHAllowedSigners = ctx.Env("SSH_ALLOWED_SIGNERS")
+	}
+
+	// git credentials, token and password can only be passed via env or file
+	j.credentials = &gitCredentials{}
+	if ctx.EnvSet("PASSWORD") {
+		j.credentials.password = ctx.Env("PASSWORD")
+	}
+	if ctx.EnvSet("TOKEN") {
+		j.credentials.password = ctx.Env("TOKEN")
+	}
+	if shared.FlagPassed(ctx, "username") {
+		j.Username = strings.TrimSpace(*j.FlagUsername)
+	}
+	if ctx.EnvSet("USERNAME") {
+		j.Username = ctx.Env("USERNAME")
+	}
+	if shared.FlagPassed(ctx, "password-file") {
+		j.PasswordFile = strings.TrimSpace(*j.FlagPasswordFile)
+	}
+	if ctx.EnvSet("PASSWORD_FILE") {
+		j.PasswordFile = ctx.Env("PASSWORD_FILE")
+	}
+	if shared.FlagPassed(ctx, "ssh-key") {
+		j.SSHKey = strings.TrimSpace(*j.FlagSSHKey)
+	}
+	if ctx.EnvSet("SSH_KEY") {
+		j.SSHKey = ctx.Env("SSH_KEY")
+	}
+	if shared.FlagPassed(ctx, "ssh-known-hosts") {
+		j.SSHKnownHosts = strings.TrimSpace(*j.FlagSSHKnownHosts)
+	}
+	if ctx.EnvSet("SSH_KNOWN_HOSTS") {
+		j.SSHKnownHosts = ctx.Env("SSH_KNOWN_HOSTS")
+	}
+	if shared.FlagPassed(ctx, "netrc") {
+		j.Netrc = strings.TrimSpace(*j.FlagNetrc)
+	}
+	if ctx.EnvSet("NETRC") {
+		j.Netrc = ctx.Env("NETRC")
+	}
+	if shared.FlagPassed(ctx, "credentials-host") {
+		j.CredentialsHost = strings.TrimSpace(*j.FlagCredentialsHost)
+	}
+	if ctx.EnvSet("CREDENTIALS_HOST") {
+		j.CredentialsHost = ctx.Env("CREDENTIALS_HOST")
+	}
+
 	// Some extra initializations
 	// NOTE: We enable pair programming by default
 	j.PairProgramming = true
@@ -751,16 +1050,25 @@ func (j *DSGit) ParseArgs(ctx *shared.Ctx) (err error) {
 
 // Validate - is current DS configuration OK?
 func (j *DSGit) Validate() (err error) {
-	url := strings.TrimSpace(j.URL)
-	if strings.HasSuffix(url, "/") {
-		url = url[:len(url)-1]
-	}
-	ary := strings.Split(url, "/")
-	j.RepoName = ary[len(ary)-1]
-	if j.RepoName == "" {
-		err = fmt.Errorf("Repo name must be set")
+	if err = j.resolveCredentials(); err != nil {
+		return
+	}
+	if err = j.resolveSource(); err != nil {
 		return
 	}
+	// In manifest mode URL and repository source are set per manifest entry
+	if j.Manifest == "" {
+		url := strings.TrimSpace(j.URL)
+		if strings.HasSuffix(url, "/") {
+			url = url[:len(url)-1]
+		}
+		ary := strings.Split(url, "/")
+		j.RepoName = ary[len(ary)-1]
+		if j.RepoName == "" {
+			err = fmt.Errorf("Repo name must be set")
+			return
+		}
+	}
 	j.ReposPath = os.ExpandEnv(j.ReposPath)
 	if strings.HasSuffix(j.ReposPath, "/") {
 		j.ReposPath = j.ReposPath[:len(j.ReposPath)-1]
@@ -769,10 +1077,44 @@ func (j *DSGit) Validate() (err error) {
 	if strings.HasSuffix(j.CachePath, "/") {
 		j.CachePath = j.CachePath[:len(j.CachePath)-1]
 	}
-	if j.RepositorySource == "" {
+	if j.RepositorySource == "" && j.Manifest == "" {
 		err = fmt.Errorf("repository source must be set, eg: git, github, gerrit")
 		return
 	}
+	switch j.CacheBackend {
+	case CacheBackendS3, CacheBackendLocal, CacheBackendMemory:
+	default:
+		err = fmt.Errorf("unknown cache backend %s, allowed: %s, %s, %s", j.CacheBackend, CacheBackendS3, CacheBackendLocal, CacheBackendMemory)
+		return
+	}
+	switch j.CloneStrategy {
+	case GitCloneStrategyFull, GitCloneStrategyBlobless, GitCloneStrategyShallowSince:
+	default:
+		err = fmt.Errorf("unknown clone strategy %s, allowed: %s, %s, %s", j.CloneStrategy, GitCloneStrategyFull, GitCloneStrategyBlobless, GitCloneStrategyShallowSince)
+		return
+	}
+	if j.Mailmap != "" {
+		j.Mailmap = os.ExpandEnv(j.Mailmap)
+		if _, err = os.Stat(j.Mailmap); err != nil {
+			err = fmt.Errorf("cannot access mailmap %s: %+v", j.Mailmap, err)
+			return
+		}
+	}
+	if j.Trailers != "" {
+		j.trailersConfig, err = ReadTrailersConfig(os.ExpandEnv(j.Trailers))
+		if err != nil {
+			return
+		}
+	}
+	botPatterns := j.BotPatterns
+	if botPatterns != "" {
+		botPatterns = os.ExpandEnv(botPatterns)
+	}
+	j.botClassifier, err = NewBotClassifier(botPatterns)
+	if err != nil {
+		return
+	}
+	j.signatureVerifier, err = NewSignatureVerifier(os.ExpandEnv(j.GPGKeyring), os.ExpandEnv(j.SSHAllowedSigners))
 	return
 }
 
@@ -795,7 +1137,13 @@ func (j *DSGit) Init(ctx *shared.Ctx) (err error) {
 		m := &git.Commit{}
 		shared.Printf("git: %+v\nshared context: %s\nModel: %+v\n", j, ctx.Info(), m)
 	}
-	if j.Stream != "" {
+	if j.LocalOutput != "" {
+		localPublisher, err := NewLocalPublisher(os.ExpandEnv(j.LocalOutput), j.LocalMaxEvents)
+		if err != nil {
+			return err
+		}
+		j.AddPublisher(localPublisher)
+	} else if j.Stream != "" {
 		sess, err := session.NewSession()
 		if err != nil {
 			return err
@@ -811,7 +1159,10 @@ func (j *DSGit) Init(ctx *shared.Ctx) (err error) {
 
 // GetCommitURL - return git commit URL for a given path and SHA
 func (j *DSGit) GetCommitURL(origin, hash string) (string, string) {
-	if strings.HasPrefix(origin, "git://") {
+	if isLocalSource(origin) {
+		// local repository or bundle without a logical origin URL has no commit links
+		return "", "local"
+	} else if strings.HasPrefix(origin, "git://") {
 		return strings.Replace(origin, "git://", "http://", 1) + "/commit/?id=" + hash, "git"
 	} else if strings.HasPrefix(origin, "http://git.") || strings.HasPrefix(origin, "https://git.") {
 		return origin + "/commit/?id=" + hash, "git"
@@ -882,8 +1233,11 @@ func (j *DSGit) GetRepoShortURL(origin string) (repoShortName string) {
 		repoShortName = lastSlashItem(arg)
 		return
 	}
-	// Fall back
+	// Fall back, local bundles and bare repositories are named after their file or directory
 	repoShortName = lastSlashItem(origin)
+	if isLocalSource(origin) {
+		repoShortName = strings.TrimSuffix(strings.TrimSuffix(repoShortName, GitBundleSuffix), ".git")
+	}
 	return
 }
 
@@ -892,10 +1246,11 @@ func (j *DSGit) GetRepoShortURL(origin string) (repoShortName string) {
 func (j *DSGit) GetOtherTrailersAuthors(ctx *shared.Ctx, doc interface{}) (othersMap map[string]map[[2]string]struct{}) {
 	// "Signed-off-by":  {"authors_signed", "signer"},
 	commitAuthor := ""
-	for otherKey, otherRichKey := range GitTrailerOtherAuthors {
+	mapping := j.trailers()
+	for otherKey, otherRichKey := range mapping.otherAuthors {
 		iothers, ok := shared.Dig(doc, []string{"data", otherKey}, false, true)
 		if ok {
-			sameAsAuthorAllowed, _ := GitTrailerSameAsAuthor[otherKey]
+			sameAsAuthorAllowed, _ := mapping.sameAsAuthor[otherKey]
 			if !sameAsAuthorAllowed {
 				if commitAuthor == "" {
 					iCommitAuthor, _ := shared.Dig(doc, []string{"data", "Author"}, true, false)
@@ -965,48 +1320,42 @@ func (j *DSGit) EnrichItem(ctx *shared.Ctx, item map[string]interface{}) (rich m
 	}
 	iAuthorDate, _ := shared.Dig(commit, []string{"AuthorDate"}, true, false)
 	sAuthorDate, _ := iAuthorDate.(string)
-	authorDate, authorDateTz, authorTz, ok := shared.ParseDateWithTz(sAuthorDate)
+	authorDate, authorDateTz, authorTz, ok := ParseDateWithTz(sAuthorDate)
 	if !ok {
 		err = fmt.Errorf("cannot parse author date from %v", iAuthorDate)
 		return
 	}
 	clocCount, _ := shared.Dig(commit, []string{"cloc_count"}, false, true)
 	rich["cloc_count"] = clocCount
-	authorLocation := time.FixedZone(fmt.Sprintf("UTC%v", authorTz), int(authorTz)*60*60)
-	authorLocalDate := time.Date(authorDate.Year(), authorDate.Month(), authorDate.Day(), authorDate.Hour(), authorDate.Minute(), authorDate.Second(), authorDate.Nanosecond(), authorLocation)
 	rich["orphaned"] = false
-	rich["tz"] = authorTz
-	rich["author_date"] = authorDateTz
-	rich["author_local_date"] = authorLocalDate.Format(time.RFC3339)
-	rich["author_date_weekday"] = int(authorDateTz.Weekday())
-	rich["author_date_hour"] = authorDateTz.Hour()
-	rich["utc_author"] = authorDate
-	rich["utc_author_date_weekday"] = int(authorDate.Weekday())
-	rich["utc_author_date_hour"] = authorDate.Hour()
+	enrichDateTz(rich, "author", "tz", authorDate, authorDateTz, authorTz)
 	iCommitDate, _ := shared.Dig(commit, []string{"CommitDate"}, true, false)
 	sCommitDate, _ := iCommitDate.(string)
-	commitDate, commitDateTz, commitTz, ok := shared.ParseDateWithTz(sCommitDate)
+	commitDate, commitDateTz, commitTz, ok := ParseDateWithTz(sCommitDate)
 	if !ok {
 		err = fmt.Errorf("cannot parse commit date from %v", iAuthorDate)
 		return
 	}
 
-	committerLocation := time.FixedZone(fmt.Sprintf("UTC%v", commitTz), int(commitTz)*60*60)
-	committerLocationDate := time.Date(commitDate.Year(), commitDate.Month(), commitDate.Day(), commitDate.Hour(), commitDate.Minute(), commitDate.Second(), commitDate.Nanosecond(), committerLocation)
-	rich["commit_tz"] = commitTz
-	rich["commit_date"] = commitDateTz
-	rich["commit_local_date"] = committerLocationDate.Format(time.RFC3339)
-	rich["commit_date_weekday"] = int(commitDateTz.Weekday())
-	rich["commit_date_hour"] = commitDateTz.Hour()
-	rich["utc_commit"] = commitDate
-	rich["utc_commit_date_weekday"] = int(commitDate.Weekday())
-	rich["utc_commit_date_hour"] = commitDate.Hour()
+	enrichDateTz(rich, "commit", "commit_tz", commitDate, commitDateTz, commitTz)
 	message, ok := shared.Dig(commit, []string{"message"}, false, true)
 	if ok {
 		msg, _ := message.(string)
 		ary := strings.Split(msg, "\n")
 		rich["title"] = ary[0]
+		breakingChanges := []string{}
+		iBreakingChanges, _ := commit["breaking_changes"].([]interface{})
+		for _, iBreakingChange := range iBreakingChanges {
+			breakingChange, _ := iBreakingChange.(string)
+			breakingChanges = append(breakingChanges, breakingChange)
+		}
+		rich["conventional_commit"] = ParseConventionalCommit(ary[0], breakingChanges)
 		rich["message_analyzed"] = msg
+		rich["references"] = ExtractReferences(msg)
+		origin := DetectCommitOrigin(msg)
+		rich["commit_type"] = origin.Type
+		rich["reverted_sha"] = origin.RevertedSHA
+		rich["cherry_picked_from"] = origin.CherryPickedFrom
 		if len(msg) > GitMaxMsgLength {
 			msg = msg[:GitMaxMsgLength]
 		}
@@ -1014,12 +1363,26 @@ func (j *DSGit) EnrichItem(ctx *shared.Ctx, item map[string]interface{}) (rich m
 	} else {
 		rich["message_analyzed"] = nil
 		rich["message"] = nil
+		rich["references"] = []CommitReference{}
+		rich["commit_type"] = ""
+		rich["reverted_sha"] = ""
+		rich["cherry_picked_from"] = ""
 	}
 	iBranch, _ := commit["branch"]
 	branch, _ := iBranch.(string)
 	rich["branch"] = branch
 	rich["default_branch"] = j.DefaultBranch
 	rich["is_default_branch"] = j.DefaultBranch == branch
+	branches, ok := commit["branches"].([]string)
+	if ok {
+		// In all branches mode commit is on the default branch when it is reachable from it
+		for _, b := range branches {
+			if b == j.DefaultBranch {
+				rich["is_default_branch"] = true
+				break
+			}
+		}
+	}
 	comm, ok := shared.Dig(commit, []string{"commit"}, false, true)
 	var hsh string
 	if ok {
@@ -1048,7 +1411,11 @@ func (j *DSGit) EnrichItem(ctx *shared.Ctx, item map[string]interface{}) (rich m
 		}
 	}
 	rich["parents"], _ = commit["parents"]
-	rich["branches"] = []interface{}{}
+	if len(branches) > 0 {
+		rich["branches"] = branches
+	} else {
+		rich["branches"] = []interface{}{}
+	}
 	dtDiff := float64(commitDate.Sub(authorDate).Seconds()) / 3600.0
 	dtDiff = math.Round(dtDiff*100.0) / 100.0
 	rich["time_to_commit_hours"] = dtDiff
@@ -1089,15 +1456,25 @@ func (j *DSGit) EnrichItem(ctx *shared.Ctx, item map[string]interface{}) (rich m
 				if ok {
 					name, _ = iName.(string)
 				}
-				fileData = append(
-					fileData,
-					map[string]interface{}{
-						"action":  action,
-						"name":    name,
-						"added":   added,
-						"removed": removed,
-					},
-				)
+				data := map[string]interface{}{
+					"action":  action,
+					"name":    name,
+					"added":   added,
+					"removed": removed,
+				}
+				oldName, ok := file["old_file"].(string)
+				if ok && oldName != "" {
+					data["old_name"] = oldName
+					data["new_name"] = name
+					data["similarity"] = file["similarity"]
+				} else if newName, _ := file["newfile"].(string); newName != "" {
+					// legacy git log parser reports old path as file and similarity in action, like R087
+					sAction, _ := action.(string)
+					data["old_name"] = name
+					data["new_name"] = newName
+					data["similarity"], _ = strconv.Atoi(strings.TrimLeft(sAction, GitFileActionRenamed+GitFileActionCopied))
+				}
+				fileData = append(fileData, data)
 			}
 		}
 	}
@@ -1156,38 +1533,55 @@ func (j *DSGit) EnrichItem(ctx *shared.Ctx, item map[string]interface{}) (rich m
 	idents := [][3]string{}
 	identTypes := []string{}
 	otherIdents := map[string][3]string{}
-	for authorStr := range authorsMap {
+	mailmapOriginals := []MailmapOriginal{}
+	// identity - return (mailmap canonicalized) identity and remember the original one when it was remapped
+	identity := func(authorStr, role string) [3]string {
+		canonical, changed := j.canonicalGitAuthor(authorStr)
 		ident, ok := otherIdents[authorStr]
 		if !ok {
-			ident = j.IdentityFromGitAuthor(ctx, authorStr)
+			ident = j.IdentityFromGitAuthor(ctx, canonical)
 			otherIdents[authorStr] = ident
 		}
-		idents = append(idents, ident)
+		if changed {
+			original := j.IdentityFromGitAuthor(ctx, authorStr)
+			mailmapOriginals = append(mailmapOriginals, MailmapOriginal{
+				Role:          role,
+				Name:          ident[0],
+				Email:         ident[2],
+				OriginalName:  original[0],
+				OriginalEmail: original[2],
+			})
+		}
+		return ident
+	}
+	for authorStr := range authorsMap {
+		idents = append(idents, identity(authorStr, "author"))
 		identTypes = append(identTypes, "author")
 	}
 	for authorStr := range committersMap {
-		ident, ok := otherIdents[authorStr]
-		if !ok {
-			ident = j.IdentityFromGitAuthor(ctx, authorStr)
-			otherIdents[authorStr] = ident
-		}
-		idents = append(idents, ident)
+		idents = append(idents, identity(authorStr, "committer"))
 		identTypes = append(identTypes, "committer")
 	}
 	for authorStr, roles := range othersMap {
-		ident, ok := otherIdents[authorStr]
-		if !ok {
-			ident = j.IdentityFromGitAuthor(ctx, authorStr)
-			otherIdents[authorStr] = ident
-		}
 		for roleData := range roles {
 			roleName := roleData[1]
-			idents = append(idents, ident)
+			idents = append(idents, identity(authorStr, roleName))
 			identTypes = append(identTypes, roleName)
 		}
 	}
 	rich["idents"] = idents
 	rich["ident_types"] = identTypes
+	rich["mailmap_originals"] = mailmapOriginals
+	signature, ok := commit["signature"].(CommitSignature)
+	if !ok {
+		signature = CommitSignature{Status: GitSignatureUnsigned}
+	}
+	rich["signature"] = signature
+	statsIncomplete, _ := commit["stats_incomplete"].(bool)
+	rich["stats_incomplete"] = statsIncomplete
+	dco, dcoAuthor := j.CheckDCO(ctx, commit)
+	j.recordDCO(dco, dcoAuthor)
+	rich["dco"] = dco
 	rich["origin"] = shared.AnonymizeURL(rich["origin"].(string))
 	rich["tags"] = ctx.Tags
 	rich["commit_url"] = shared.AnonymizeURL(rich["commit_url"].(string))
@@ -1242,8 +1636,8 @@ func (j *DSGit) dedupAuthors(inContributors []insights.Contributor) (outContribu
 }
 
 // GetModelData - return data in lfx-event-schema format
-func (j *DSGit) GetModelData(ctx *shared.Ctx, docs []interface{}) []git.CommitCreatedEvent {
-	data := make([]git.CommitCreatedEvent, 0)
+func (j *DSGit) GetModelData(ctx *shared.Ctx, docs []interface{}) []CommitCreatedEvent {
+	data := make([]CommitCreatedEvent, 0)
 	baseEvent := service.BaseEvent{
 		Type: CommitCreated,
 		CRUDInfo: service.CRUDInfo{
@@ -1264,12 +1658,13 @@ func (j *DSGit) GetModelData(ctx *shared.Ctx, docs []interface{}) []git.CommitCr
 		j.log.WithFields(logrus.Fields{"operation": "GetModelData"}).Error(fmt.Errorf("GenerateRepositoryID source id: %s, url: %s, source: %s.error:  %+v", j.SourceID, j.URL, j.RepositorySource, err))
 	}
 	for _, iDoc := range docs {
-		commit := git.Commit{}
+		commit := Commit{}
 		doc, _ := iDoc.(map[string]interface{})
 		commit.URL, _ = doc["commit_url"].(string)
 		commit.SHA, _ = doc["hash"].(string)
 		commit.Branch, _ = doc["branch"].(string)
 		commit.DefaultBranch, _ = doc["is_default_branch"].(bool)
+		commit.Branches, _ = doc["branches"].([]string)
 		commit.ShortHash, _ = doc["hash_short"].(string)
 		commit.DocCommit, _ = doc["doc_commit"].(bool)
 		commit.Message, _ = doc["message"].(string)
@@ -1336,8 +1731,18 @@ func (j *DSGit) GetModelData(ctx *shared.Ctx, docs []interface{}) []git.CommitCr
 				commitRoles = append(commitRoles, commitRole)
 			}
 		}
-		commit.Contributors = j.dedupAuthors(shared.DedupContributors(commitRoles))
-		fileCache := make(map[string]*git.CommitFilesByType)
+		commit.Contributors = j.classifyContributors(j.dedupAuthors(shared.DedupContributors(commitRoles)))
+		commit.MailmapOriginals, _ = doc["mailmap_originals"].([]MailmapOriginal)
+		commit.References, _ = doc["references"].([]CommitReference)
+		commit.ConventionalCommit, _ = doc["conventional_commit"].(*ConventionalCommit)
+		commit.CommitType, _ = doc["commit_type"].(string)
+		commit.DCO, _ = doc["dco"].(CommitDCO)
+		commit.Signature, _ = doc["signature"].(CommitSignature)
+		commit.RevertedSHA, _ = doc["reverted_sha"].(string)
+		commit.CherryPickedFrom, _ = doc["cherry_picked_from"].(string)
+		commit.StatsIncomplete, _ = doc["stats_incomplete"].(bool)
+		fileCache := make(map[string]*CommitFilesByType)
+		langCache := make(map[string]*CommitLanguage)
 		fileAry, okFileAry := doc["file_data"].([]map[string]interface{})
 		if okFileAry {
 			for _, fileData := range fileAry {
@@ -1347,45 +1752,68 @@ func (j *DSGit) GetModelData(ctx *shared.Ctx, docs []interface{}) []git.CommitCr
 				}
 				ext := ParseFileExtension(fileName)
 				if _, ok := fileCache[ext]; !ok {
-					fileCache[ext] = &git.CommitFilesByType{Type: ext}
+					fileCache[ext] = &CommitFilesByType{CommitFilesByType: git.CommitFilesByType{Type: ext}}
 				}
 				obj := fileCache[ext]
 				linesAdded, _ := fileData["added"].(int)
 				obj.LinesAdded += linesAdded
 				linesRemoved, _ := fileData["removed"].(int)
 				obj.LinesRemoved += linesRemoved
+				lang := DetectLanguage(fileName)
+				if lang == "" {
+					lang = LocOtherLanguage
+				}
+				if _, ok := langCache[lang]; !ok {
+					langCache[lang] = &CommitLanguage{Language: lang}
+				}
+				langObj := langCache[lang]
+				langObj.Files++
+				langObj.LinesAdded += linesAdded
+				langObj.LinesRemoved += linesRemoved
 				action, _ := fileData["action"].(string)
+				// legacy git log parser reports renames and copies with similarity score, like R100 or C075
 				if action == "M" {
 					obj.FilesModified++
 				} else if action == "D" {
 					obj.FilesDeleted++
+				} else if strings.HasPrefix(action, GitFileActionRenamed) {
+					obj.FilesRenamed++
+					commit.RenamedFiles = append(commit.RenamedFiles, renamedFile(fileData))
+				} else if strings.HasPrefix(action, GitFileActionCopied) {
+					obj.FilesCopied++
+					commit.CopiedFiles = append(commit.CopiedFiles, renamedFile(fileData))
 				} else {
 					obj.FilesCreated++
 				}
 			}
-			commit.Files = make([]git.CommitFilesByType, 0)
+			commit.Files = make([]CommitFilesByType, 0)
 			for _, value := range fileCache {
 				commit.Files = append(commit.Files, *value)
 			}
-			if len(commit.Files) > 0 {
-				clocCount, _ := doc["cloc_count"].(int)
-				if clocCount != 0 {
-					commit.Files[len(commit.Files)-1].ActualLinesOfCode = clocCount
-				}
+			sort.Slice(commit.Files, func(i, k int) bool {
+				return commit.Files[i].Type < commit.Files[k].Type
+			})
+			for _, value := range langCache {
+				commit.Languages = append(commit.Languages, *value)
 			}
+			sort.Slice(commit.Languages, func(i, k int) bool {
+				return commit.Languages[i].Language < commit.Languages[k].Language
+			})
 		}
-		commit.MergeCommit = len(fileAry) == 0
+		commit.LinesOfCode, _ = doc["cloc_count"].(int)
+		// commits without file stats from partial or shallow clones are not merges
+		commit.MergeCommit = len(fileAry) == 0 && !commit.StatsIncomplete
 		// Event
-		data = append(data, git.CommitCreatedEvent{
+		data = append(data, CommitCreatedEvent{
 			CommitBaseEvent: commitBaseEvent,
 			BaseEvent:       baseEvent,
 			Payload:         commit,
 		})
-		gMaxUpstreamDtMtx.Lock()
-		if createdOn.After(gMaxUpstreamDt) && createdOn.Before(time.Now().UTC()) {
-			gMaxUpstreamDt = createdOn
+		j.state.maxUpstreamDtMtx.Lock()
+		if createdOn.After(j.state.maxUpstreamDt) && createdOn.Before(time.Now().UTC()) {
+			j.state.maxUpstreamDt = createdOn
 		}
-		gMaxUpstreamDtMtx.Unlock()
+		j.state.maxUpstreamDtMtx.Unlock()
 	}
 	return data
 }
@@ -1406,7 +1834,7 @@ func (j *DSGit) ItemUpdatedOn(item interface{}) time.Time {
 	if !ok {
 		shared.Fatalf("git: ItemUpdatedOn() - cannot extract %s from %+v", GitCommitDateField, shared.DumpKeys(item))
 	}
-	updated, _, _, ok := shared.ParseDateWithTz(sUpdated)
+	updated, _, _, ok := ParseDateWithTz(sUpdated)
 	if !ok {
 		shared.Fatalf("git: ItemUpdatedOn() - cannot extract %s from %s", GitCommitDateField, sUpdated)
 	}
@@ -1444,81 +1872,45 @@ func (j *DSGit) AddMetadata(ctx *shared.Ctx, item interface{}) (mItem map[string
 	return
 }
 
-// GetGitOps - LOC, lang summary stats
-func (j *DSGit) GetGitOps(ctx *shared.Ctx, thrN int) (ch chan error, err error) {
-	worker := func(c chan error, url string) (e error) {
+// GetGitOps - LOC, lang summary stats of HEAD, counted in-process from the repository blobs
+func (j *DSGit) GetGitOps(ctx *shared.Ctx, r *goGit.Repository, thrN int) (ch chan error, err error) {
+	worker := func(c chan error) (e error) {
 		defer func() {
 			if c != nil {
 				c <- e
 			}
 		}()
 		var (
-			sout string
-			serr string
+			ref *plumbing.Reference
+			res map[string]clocResult
 		)
-		cmdLine := []string{GitOpsCommand, url}
-		env := map[string]string{
-			"DA_GIT_REPOS_PATH": j.ReposPath,
-			"DA_GIT_CACHE_PATH": j.CachePath,
-		}
-		if j.SkipCacheCleanup {
-			env["SKIP_CLEANUP"] = "1"
+		ref, e = r.Head()
+		if e == nil {
+			res, e = j.countHeadLoc(r, ref.Hash().String())
 		}
-		sout, serr, e = shared.ExecCommand(ctx, cmdLine, "", env)
 		if e != nil {
-			if GitOpsFailureFatal {
-				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Error(fmt.Errorf("error executing command: %v, error: %v, output: %s, error output: %s", cmdLine, e, sout, serr))
+			if GitOpsFailureFatal && !(j.isPartialClone() && isMissingObject(e)) {
+				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Errorf("error counting lines of code in %s: %v", j.GitPath, e)
 			} else {
-				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Warningf("WARNING: error executing command: %v, error: %v, output: %s, error output: %s", cmdLine, e, sout, serr)
-				e = nil
-			}
-			return
-		}
-		type resultType struct {
-			Loc int      `json:"loc"`
-			Pls []RawPLS `json:"pls"`
-		}
-		var data resultType
-		e = jsoniter.Unmarshal([]byte(sout), &data)
-		if e != nil {
-			if GitOpsFailureFatal {
-				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Errorf("error unmarshaling from %v", sout)
-			} else {
-				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Warningf("warning unmarshaling from %v", sout)
+				j.log.WithFields(logrus.Fields{"operation": "GetGitOps"}).Warningf("WARNING: error counting lines of code in %s: %v", j.GitPath, e)
 				e = nil
 			}
 			return
 		}
 		j.StatsDt = time.Now()
-		j.Loc = data.Loc
-		for _, f := range data.Pls {
-			files, _ := strconv.Atoi(f.Files)
-			blank, _ := strconv.Atoi(f.Blank)
-			comment, _ := strconv.Atoi(f.Comment)
-			code, _ := strconv.Atoi(f.Code)
-			j.Pls = append(
-				j.Pls,
-				PLS{
-					Language: f.Language,
-					Files:    files,
-					Blank:    blank,
-					Comment:  comment,
-					Code:     code,
-				},
-			)
-		}
+		j.Loc, j.Pls = locSummary(res)
 		return
 	}
 	if thrN <= 1 {
-		return nil, worker(nil, j.URL)
+		return nil, worker(nil)
 	}
 	ch = make(chan error)
-	go func() { _ = worker(ch, j.URL) }()
+	go func() { _ = worker(ch) }()
 	return ch, nil
 }
 
-// CreateGitRepo - clone git repo if needed
-func (j *DSGit) CreateGitRepo(ctx *shared.Ctx) (err error) {
+// CreateGitRepo - clone git repo if needed, clone is killed and removed when cancelCtx is cancelled
+func (j *DSGit) CreateGitRepo(cancelCtx context.Context, ctx *shared.Ctx) (err error) {
 	info, err := os.Stat(j.GitPath)
 
 	var exists bool
@@ -1534,10 +1926,25 @@ func (j *DSGit) CreateGitRepo(ctx *shared.Ctx) (err error) {
 		if ctx.Debug > 0 {
 			j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Debugf("cloning %s to %s", j.URL, j.GitPath)
 		}
-		cmdLine := []string{"git", "clone", "--bare", j.URL, j.GitPath}
+		cloneArgs := j.cloneArgs(ctx)
+		cmdLine := append(append([]string{"git", "clone", "--bare"}, cloneArgs...), j.cloneURL(), j.GitPath)
 		env := map[string]string{"LANG": "C"}
 		var sout, serr string
-		sout, serr, err = shared.ExecCommand(ctx, cmdLine, "", env)
+		sout, serr, err = execCommand(cancelCtx, cmdLine, "", j.gitAuthEnv(env))
+		if err != nil && err != ErrSyncCancelled && len(cloneArgs) > 0 {
+			// server or local source may not support partial or shallow clones
+			if err = j.cloneFallback(fmt.Errorf("%v: %s", err, serr)); err != nil {
+				return
+			}
+			cmdLine = []string{"git", "clone", "--bare", j.cloneURL(), j.GitPath}
+			sout, serr, err = execCommand(cancelCtx, cmdLine, "", j.gitAuthEnv(env))
+		}
+		if err == ErrSyncCancelled {
+			// interrupted clone is incomplete, next run clones again
+			j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Warningf("clone of %s cancelled, removing %s", j.URL, j.GitPath)
+			_ = os.RemoveAll(j.GitPath)
+			return
+		}
 		if err != nil {
 			j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Errorf("error executing command: %v, error: %v, output: %s, output error: %s", cmdLine, err, sout, serr)
 			return
@@ -1558,14 +1965,20 @@ func (j *DSGit) CreateGitRepo(ctx *shared.Ctx) (err error) {
 	return
 }
 
-// UpdateGitRepo - update git repo
-func (j *DSGit) UpdateGitRepo(ctx *shared.Ctx) (err error) {
+// UpdateGitRepo - update git repo, fetch is killed when cancelCtx is cancelled
+func (j *DSGit) UpdateGitRepo(cancelCtx context.Context, ctx *shared.Ctx) (err error) {
 	if ctx.Debug > 0 {
 		j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Debugf("updating repo %s", j.URL)
 	}
 	cmdLine := []string{"git", "fetch", "origin", "+refs/heads/*:refs/heads/*", "--prune"}
+	if j.TagEvents {
+		cmdLine = append(cmdLine, "+refs/tags/*:refs/tags/*")
+	}
 	var sout, serr string
-	sout, serr, err = shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
+	sout, serr, err = execCommand(cancelCtx, cmdLine, j.GitPath, j.gitAuthEnv(GitDefaultEnv))
+	if err == ErrSyncCancelled {
+		return
+	}
 	if err != nil {
 		j.log.WithFields(logrus.Fields{"operation": "CreateGitRepo"}).Errorf("error executing %v: %v\n%s\n%s", cmdLine, err, sout, serr)
 		return
@@ -1577,8 +1990,8 @@ func (j *DSGit) UpdateGitRepo(ctx *shared.Ctx) (err error) {
 }
 
 // GetOrphanedCommits - return data about orphaned commits: commits present in git object storage
-// but not present in rev-list - for example squashed commits
-func (j *DSGit) GetOrphanedCommits(ctx *shared.Ctx, thrN int) (ch chan error, err error) {
+// but not reachable from any ref - for example squashed commits
+func (j *DSGit) GetOrphanedCommits(ctx *shared.Ctx, r *goGit.Repository, thrN int) (ch chan error, err error) {
 	worker := func(c chan error) (e error) {
 		if ctx.Debug > 0 {
 			j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Debug("searching for orphaned commits")
@@ -1588,30 +2001,20 @@ func (j *DSGit) GetOrphanedCommits(ctx *shared.Ctx, thrN int) (ch chan error, er
 				c <- e
 			}
 		}()
-		var (
-			sout string
-			serr string
-		)
-		cmdLine := []string{OrphanedCommitsCommand}
-		sout, serr, e = shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
+		e = unreachableCommits(r, func(sha string) error {
+			j.OrphanedCommits = append(j.OrphanedCommits, sha)
+			j.OrphanedMap[sha] = struct{}{}
+			return nil
+		})
 		if e != nil {
 			if OrphanedCommitsFailureFatal {
-				j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Errorf("error executing %v: %v\n%s\n%s", cmdLine, e, sout, serr)
+				j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Errorf("error searching for orphaned commits in %s: %v", j.GitPath, e)
 			} else {
-				j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Warningf("WARNING: error executing %v: %v\n%s\n%s", cmdLine, e, sout, serr)
+				j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Warningf("WARNING: error searching for orphaned commits in %s: %v", j.GitPath, e)
 				e = nil
 			}
 			return
 		}
-		ary := strings.Split(sout, " ")
-		for _, sha := range ary {
-			sha = strings.TrimSpace(sha)
-			if sha == "" {
-				continue
-			}
-			j.OrphanedCommits = append(j.OrphanedCommits, sha)
-			j.OrphanedMap[sha] = struct{}{}
-		}
 		j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Infof("found %d orphaned commits", len(j.OrphanedCommits))
 		if ctx.Debug > 1 {
 			j.log.WithFields(logrus.Fields{"operation": "GetOrphanedCommits"}).Debugf("OrphanedCommits: %+v", j.OrphanedCommits)
@@ -1803,31 +2206,39 @@ func (j *DSGit) GetOtherPPAuthors(ctx *shared.Ctx, doc interface{}) (othersMap m
 // GitEnrichItems - iterate items and enrich them
 // items is a current pack of input items
 // docs is a pointer to where extracted identities will be stored
-func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, docs *[]interface{}, final bool) (err error) {
+// once the sync is cancelled only the final call processes items, so they are flushed together with remaining docs
+func (j *DSGit) GitEnrichItems(cancelCtx context.Context, ctx *shared.Ctx, thrN int, items []interface{}, docs *[]interface{}, final bool) (err error) {
+	if !final && syncCancelled(cancelCtx) {
+		err = ErrSyncCancelled
+		return
+	}
 	j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Debugf("input processing(%d/%d/%v)", len(items), len(*docs), final)
 	outputDocs := func() {
 		if len(*docs) > 0 {
 			// actual output
 			j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Infof("output processing(%d/%d/%v)", len(items), len(*docs), final)
 			data := j.GetModelData(ctx, *docs)
+			// commits which were pushed or were already cached, others are walked again when resuming from a checkpoint
+			shas := make([]string, 0, len(data))
 			if j.Publisher != nil {
 				formattedData := make([]interface{}, 0)
 				updatedData := make([]interface{}, 0)
 				commits := make([]CommitCache, 0)
 				updateCommits := make([]CommitCache, 0)
 				for _, d := range data {
-					contentHash, er := createHash(d.Payload)
+					contentHash, er := createHash(d.Payload.Commit)
 					if er != nil {
-						j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Errorf("error hash data for commit %s, error %v", d.Payload, err)
+						j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Errorf("error hash data for commit %s, error %v", d.Payload, er)
 						continue
 					}
 					commitB, err := jsoniter.Marshal(d.Payload)
 					if err != nil {
 						return
 					}
+					shas = append(shas, d.Payload.SHA)
 					commitStr := b64.StdEncoding.EncodeToString(commitB)
-					hashExist := isHashCreated(contentHash)
-					isCreated := isCommitCreated(d.Payload.ID)
+					hashExist := j.state.isHashCreated(contentHash)
+					isCreated := j.state.isCommitCreated(d.Payload.ID)
 					if !hashExist && !isCreated {
 						formattedData = append(formattedData, d)
 						tStamp := d.Payload.SyncTimestamp.Unix()
@@ -1839,14 +2250,14 @@ func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, d
 							Hash:           contentHash,
 							CommitDate:     d.Payload.CommittedTimestamp,
 						}
-						if IsHotRep {
+						if j.state.isHotRepo {
 							comm.Content = ""
 						}
 						commits = append(commits, comm)
-						createdCommits[d.Payload.ID] = true
+						j.state.createdCommits[d.Payload.ID] = true
 					}
 					if isCreated && !hashExist {
-						updatedEvent := git.CommitUpdatedEvent{
+						updatedEvent := CommitUpdatedEvent{
 							CommitBaseEvent: d.CommitBaseEvent,
 							BaseEvent: service.BaseEvent{
 								Type:     CommitUpdated,
@@ -1864,7 +2275,7 @@ func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, d
 							Hash:           contentHash,
 							CommitDate:     d.Payload.CommittedTimestamp,
 						}
-						if IsHotRep {
+						if j.state.isHotRepo {
 							comm.Content = ""
 						}
 						updateCommits = append(updateCommits, comm)
@@ -1877,7 +2288,7 @@ func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, d
 						j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Errorf("Error: %+v", err)
 						return
 					}
-					if !IsHotRep {
+					if !j.state.isHotRepo {
 						if err = j.createCacheFile(commits, path); err != nil {
 							return
 						}
@@ -1893,7 +2304,7 @@ func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, d
 						j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Errorf("Error: %+v", err)
 						return
 					}
-					if !IsHotRep {
+					if !j.state.isHotRepo {
 						if err = j.createCacheFile(updateCommits, path); err != nil {
 							return
 						}
@@ -1913,7 +2324,11 @@ func (j *DSGit) GitEnrichItems(ctx *shared.Ctx, thrN int, items []interface{}, d
 					return
 				}
 				j.log.WithFields(logrus.Fields{"operation": "GitEnrichItems"}).Infof("%s", string(jsonBytes))
+				for _, d := range data {
+					shas = append(shas, d.Payload.SHA)
+				}
 			}
+			j.state.checkpoint.commitsPublished(shas)
 			*docs = []interface{}{}
 			err = j.setLastSync(ctx)
 			if err != nil {
@@ -2311,7 +2726,7 @@ func (j *DSGit) ParseMessage(ctx *shared.Ctx, line string) (parsed bool, err err
 	} else {
 		j.Commit["message"] = msg
 	}
-	j.ParseTrailer(ctx, msg)
+	j.ParseTrailer(ctx, j.Commit, msg)
 	parsed = true
 	return
 }
@@ -2355,23 +2770,27 @@ func ParseFileExtension(filename string) string {
 	return extension
 }
 
-// ParseTrailer - parse possible trailer line
-func (j *DSGit) ParseTrailer(ctx *shared.Ctx, line string) {
+// ParseTrailer - parse possible trailer line and store it in commit
+func (j *DSGit) ParseTrailer(ctx *shared.Ctx, commit map[string]interface{}, line string) {
+	if ParseBreakingChange(commit, line) {
+		return
+	}
 	m := shared.MatchGroups(GitTrailerPattern, line)
 	if len(m) == 0 {
 		return
 	}
 	oTrailer := m["name"]
 	lTrailer := strings.ToLower(oTrailer)
-	trailers, ok := GitAllowedTrailers[lTrailer]
+	allowed := j.trailers().allowed
+	trailers, ok := allowed[lTrailer]
 	if !ok {
 		if ctx.Debug > 1 {
-			j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("Trailer %s/%s not in the allowed list %v, skipping", oTrailer, lTrailer, GitAllowedTrailers)
+			j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("Trailer %s/%s not in the allowed list %v, skipping", oTrailer, lTrailer, allowed)
 		}
 		return
 	}
 	for _, trailer := range trailers {
-		ary, ok := j.Commit[trailer]
+		ary, ok := commit[trailer]
 		if ok {
 			if ctx.Debug > 1 {
 				j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("trailer %s -> %s found in '%s'", oTrailer, trailer, line)
@@ -2380,26 +2799,26 @@ func (j *DSGit) ParseTrailer(ctx *shared.Ctx, line string) {
 			_, ok = ary.(string)
 			if ok {
 				trailer += "-Trailer"
-				ary2, ok2 := j.Commit[trailer]
+				ary2, ok2 := commit[trailer]
 				if ok2 {
 					if ctx.Debug > 1 {
 						j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("renamed trailer %s -> %s found in '%s'", oTrailer, trailer, line)
 					}
-					j.Commit[trailer] = append(ary2.([]interface{}), m["value"])
+					commit[trailer] = append(ary2.([]interface{}), m["value"])
 				} else {
 					if ctx.Debug > 1 {
 						j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("added renamed trailer %s", trailer)
 					}
-					j.Commit[trailer] = []interface{}{m["value"]}
+					commit[trailer] = []interface{}{m["value"]}
 				}
 			} else {
-				j.Commit[trailer] = shared.UniqueStringArray(append(ary.([]interface{}), m["value"]))
+				commit[trailer] = shared.UniqueStringArray(append(ary.([]interface{}), m["value"]))
 				if ctx.Debug > 1 {
 					j.log.WithFields(logrus.Fields{"operation": "ParseTrailer"}).Debugf("appended trailer %s -> %s found in '%s'", oTrailer, trailer, line)
 				}
 			}
 		} else {
-			j.Commit[trailer] = []interface{}{m["value"]}
+			commit[trailer] = []interface{}{m["value"]}
 		}
 	}
 }
@@ -2420,7 +2839,8 @@ func (j *DSGit) HandleRecentLines(line string) {
 	}
 }
 
-func (j *DSGit) BuildCommitMap(comm object.Commit) (map[string]interface{}, error) {
+// BuildCommitMap - build raw commit from go-git commit, file changes computation stops when the sync is cancelled
+func (j *DSGit) BuildCommitMap(cancelCtx context.Context, ctx *shared.Ctx, comm object.Commit) (map[string]interface{}, error) {
 	commit := make(map[string]interface{})
 	commit["commit"] = comm.Hash.String()
 	parents := make([]string, 0)
@@ -2437,45 +2857,46 @@ func (j *DSGit) BuildCommitMap(comm object.Commit) (map[string]interface{}, erro
 	commit["Commit"] = comm.Committer.String()
 	commit["CommitDate"] = comm.Committer.When.Format(time.RFC1123Z)
 	commit["AuthorDate"] = comm.Author.When.Format(time.RFC1123Z)
-	files := make([]map[string]interface{}, 0)
-	states, err := comm.Stats()
-	if err != nil {
-		return commit, err
+	if j.TrailerAuthors {
+		// trailers are parsed into the commit, like git log parser does, so enrichment adds their authors
+		for _, line := range strings.Split(commit["message"].(string), "\n") {
+			j.ParseTrailer(ctx, commit, strings.TrimSpace(line))
+		}
+	} else {
+		trailers := j.ParseMessageTrailers(ctx, commit["message"].(string))
+		commit["message_trailers"] = trailers
+		if breakingChanges, ok := trailers["breaking_changes"]; ok {
+			commit["breaking_changes"] = breakingChanges
+		}
 	}
-
-	fileStates := make(map[string]object.FileStat)
+	commit["signature"] = j.signatureVerifier.Verify(&comm)
+	files := make([]map[string]interface{}, 0)
 	doc := false
-	for _, s := range states {
-		fileStates[s.Name] = s
-	}
-
-	allFiles, err := getFilesAction(comm)
+	changes, complete, err := getFilesChanges(cancelCtx, comm)
 	if err != nil {
-		return commit, err
-	}
-
-	for k, v := range allFiles {
-		s, ok := fileStates[k]
-		if ok {
-			f := make(map[string]interface{})
-			f["file"] = s.Name
-			f["added"] = s.Addition
-			f["removed"] = s.Deletion
-			f["action"] = v
-			if GitDocFilePattern.MatchString(s.Name) {
-				doc = true
-			}
-			files = append(files, f)
-		} else {
-			f := make(map[string]interface{})
-			f["file"] = k
-			f["action"] = v
-			if GitDocFilePattern.MatchString(k) {
-				doc = true
-			}
-			files = append(files, f)
+		if !isMissingObject(err) {
+			return commit, err
+		}
+		// parent of a shallow clone boundary commit is missing, there is nothing to compare with
+		j.log.WithFields(logrus.Fields{"operation": "BuildCommitMap"}).Warningf("WARNING: %s: no file stats: %v", commit["commit"], err)
+	}
+	if !complete {
+		commit["stats_incomplete"] = true
+	}
+	for _, change := range changes {
+		f := make(map[string]interface{})
+		f["file"] = change.Path
+		f["added"] = change.Added
+		f["removed"] = change.Removed
+		f["action"] = change.Action
+		if change.OldPath != "" {
+			f["old_file"] = change.OldPath
+			f["similarity"] = change.Similarity
+		}
+		if GitDocFilePattern.MatchString(change.Path) {
+			doc = true
 		}
-
+		files = append(files, f)
 	}
 
 	commit["files"] = files
@@ -2483,56 +2904,6 @@ func (j *DSGit) BuildCommitMap(comm object.Commit) (map[string]interface{}, erro
 	return commit, nil
 }
 
-func getFilesAction(com object.Commit) (map[string]string, error) {
-	t, _ := com.Tree()
-	toTree := &object.Tree{}
-	if com.NumParents() != 0 {
-		firstParent, err := com.Parents().Next()
-		if err != nil {
-			return map[string]string{}, err
-		}
-
-		toTree, err = firstParent.Tree()
-		if err != nil {
-			return map[string]string{}, err
-		}
-	}
-	patch, err := toTree.PatchContext(context.Background(), t)
-	if err != nil {
-		return map[string]string{}, err
-	}
-
-	fs := patch.FilePatches()
-	filesAction := make(map[string]string, len(fs))
-	for _, fp := range fs {
-		from, to := fp.Files()
-
-		if from != nil && to != nil {
-			if from.Path() != to.Path() {
-				// file is renamed which we ignored
-				continue
-			}
-			filesAction[from.Path()] = "M"
-			continue
-		}
-
-		if to == nil {
-			if from != nil {
-				filesAction[from.Path()] = "D"
-				continue
-			}
-		}
-
-		if from == nil {
-			if to != nil {
-				filesAction[to.Path()] = ""
-				continue
-			}
-		}
-	}
-	return filesAction, nil
-}
-
 // ParseNextCommit - parse next git log commit or report end
 func (j *DSGit) ParseNextCommit(ctx *shared.Ctx) (commit map[string]interface{}, ok bool, err error) {
 	for j.LineScanner.Scan() {
@@ -2602,6 +2973,11 @@ func (j *DSGit) ParseNextCommit(ctx *shared.Ctx) (commit map[string]interface{},
 
 // Sync - sync git data source
 func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
+	j.state = newSyncState()
+	j.state.trailers, err = j.trailersConfig.resolve(ctx.Project)
+	if err != nil {
+		return
+	}
 	thrN := shared.GetThreadsNum(ctx)
 	lastSync := os.Getenv("LAST_SYNC")
 	if lastSync != "" {
@@ -2659,12 +3035,6 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 		allCommitsMtx = &sync.Mutex{}
 		eschaMtx = &sync.Mutex{}
 		waitLOCMtx = &sync.Mutex{}
-		goch, _ = j.GetGitOps(ctx, thrN)
-	} else {
-		_, err = j.GetGitOps(ctx, thrN)
-		if err != nil {
-			return
-		}
 	}
 	// Do normal git processing, which don't needs gitops yet
 	j.GitPath = j.ReposPath + "/" + j.URL + "-git"
@@ -2673,12 +3043,29 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 	if ctx.Debug > 0 {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Debugf("path to store git repository: %s", j.GitPath)
 	}
-	shared.FatalOnError(j.CreateGitRepo(ctx))
-	shared.FatalOnError(j.UpdateGitRepo(ctx))
+	shared.FatalOnError(j.CreateGitRepo(context.Background(), ctx))
+	shared.FatalOnError(j.UpdateGitRepo(context.Background(), ctx))
+	r, err := goGit.PlainOpen(j.GitPath)
+	if err != nil {
+		return
+	}
+	j.setCloneStrategy(r)
+	if e := j.loadMailmap(ctx, r); e != nil {
+		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Warningf("cannot load mailmap, identities are not canonicalized: %v", e)
+	}
 	if thrN > 1 {
-		occh, _ = j.GetOrphanedCommits(ctx, thrN)
+		goch, _ = j.GetGitOps(ctx, r, thrN)
 	} else {
-		_, err = j.GetOrphanedCommits(ctx, thrN)
+		_, err = j.GetGitOps(ctx, r, thrN)
+		if err != nil {
+			return
+		}
+	}
+	locCounter := newLocCounter()
+	if thrN > 1 {
+		occh, _ = j.GetOrphanedCommits(ctx, r, thrN)
+	} else {
+		_, err = j.GetOrphanedCommits(ctx, r, thrN)
 		if err != nil {
 			return
 		}
@@ -2750,19 +3137,16 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 	}
 	processCommit := func(c chan error, commit map[string]interface{}) (wch chan error, e error) {
 		sha, _ := commit["commit"].(string)
-		cmdLine := []string{"cloc", "commit", sha, "--json"}
-		sout, serr, err := shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
+		res, err := locCounter.CountCommit(r, sha)
 		if err != nil {
-			j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error executing command: %v, error: %v, output: %s, output error: %s", cmdLine, err, sout, serr)
-			return
+			// report commit without lines of code, returning here would never signal the channel
+			j.log.WithFields(logrus.Fields{"operation": "Sync"}).Warningf("WARNING: error counting lines of code of %s: %v", sha, err)
+		} else {
+			commit["cloc_count"] = res[LocSumKey].Code
 		}
-		r := make(map[string]clocResult)
-		err = jsoniter.Unmarshal([]byte(sout), &r)
-		if err != nil {
-			j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error unmarshall: %v, error: %v", sout, err)
-			return
+		if gc, err := r.CommitObject(plumbing.NewHash(sha)); err == nil {
+			commit["signature"] = j.signatureVerifier.Verify(gc)
 		}
-		commit["cloc_count"] = r["SUM"].Code
 		defer func() {
 			if c != nil {
 				c <- e
@@ -2792,7 +3176,7 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 					}
 				}()
 				// ee = SendToQueue(ctx, j, true, UUID, allCommits)
-				ee = j.GitEnrichItems(ctx, thrN, allCommits, &allDocs, false)
+				ee = j.GitEnrichItems(context.Background(), ctx, thrN, allCommits, &allDocs, false)
 				if ee != nil {
 					j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error %v sending %d commits to queue", ee, len(allCommits))
 				}
@@ -2910,7 +3294,7 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 	}
 	// NOTE: for all items, even if 0 - to flush the queue
 	// err = SendToQueue(ctx, j, true, UUID, allCommits)
-	err = j.GitEnrichItems(ctx, thrN, allCommits, &allDocs, true)
+	err = j.GitEnrichItems(context.Background(), ctx, thrN, allCommits, &allDocs, true)
 	if err != nil {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("Error %v sending %d commits to queue", err, len(allCommits))
 	}
@@ -2933,10 +3317,24 @@ func (j *DSGit) Sync(ctx *shared.Ctx) (err error) {
 	}
 	// NOTE: Non-generic ends here
 	err = j.setLastSync(ctx)
+	if err != nil {
+		return
+	}
+	if e := j.ReportDCO(ctx); e != nil {
+		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("Error writing DCO report: %v", e)
+	}
 	return
 }
 
-func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
+// SyncV2 - sync commits walking repository history in 30 days windows
+// when cancelCtx is cancelled no new commits are processed, processed ones are flushed, last sync is saved and ErrSyncCancelled is returned
+// if the flush fails its error is returned and last sync is not saved
+func (j *DSGit) SyncV2(cancelCtx context.Context, ctx *shared.Ctx) (err error) {
+	j.state = newSyncState()
+	j.state.trailers, err = j.trailersConfig.resolve(ctx.Project)
+	if err != nil {
+		return
+	}
 	thrN := 1 //shared.GetThreadsNum(ctx)
 	lastSync := os.Getenv("LAST_SYNC")
 	if lastSync != "" {
@@ -2951,24 +3349,19 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Infof("%s fetching from %v (%d threads)", j.URL, ctx.DateFrom, thrN)
 	}
 	if ctx.DateFrom == nil {
-		lastSyncDataB, er := j.cacheProvider.GetLastSyncFile(j.endpoint)
+		lastSyncData, er := j.getLastSyncData()
 		if er != nil {
 			err = er
 			return
 		}
-		var lastSyncData lastSyncFile
-		if er = jsoniter.Unmarshal(lastSyncDataB, &lastSyncData); er != nil {
-			var cachedLastSync time.Time
-			err = jsoniter.Unmarshal(lastSyncDataB, &cachedLastSync)
-			if err != nil {
-				err = er
-				return
-			}
-			lastSyncData = lastSyncFile{
-				LastSync: cachedLastSync,
-			}
-		}
 		ctx.DateFrom = &lastSyncData.LastSync
+		if cp := lastSyncData.Checkpoint; cp != nil && !cp.Window.IsZero() {
+			// walk again the window the previous sync stopped in, skipping its published commits
+			ctx.DateFrom = &cp.Window
+			j.state.checkpoint.resume(cp)
+			j.state.maxUpstreamDt = lastSyncData.LastSync
+			j.log.WithFields(logrus.Fields{"operation": "Sync"}).Infof("%s resuming from checkpoint %v, %d commits already published", j.URL, cp.Window, len(cp.Published))
+		}
 		if ctx.DateFrom != nil {
 			j.log.WithFields(logrus.Fields{"operation": "Sync"}).Infof("%s resuming from %v (%d threads)", j.URL, ctx.DateFrom, thrN)
 		}
@@ -2993,12 +3386,6 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		allCommitsMtx = &sync.Mutex{}
 		eschaMtx = &sync.Mutex{}
 		waitLOCMtx = &sync.Mutex{}
-		goch, _ = j.GetGitOps(ctx, thrN)
-	} else {
-		_, err = j.GetGitOps(ctx, thrN)
-		if err != nil {
-			return
-		}
 	}
 	// Do normal git processing, which don't needs gitops yet
 	j.GitPath = j.ReposPath + "/" + j.URL + "-git"
@@ -3008,17 +3395,31 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Debugf("path to store git repository: %s", j.GitPath)
 	}
 
-	r, err := j.cloneRepoCommand(ctx)
+	if j.CloneStrategy == GitCloneStrategyShallowSince {
+		j.loadPreviousSync()
+	}
+	r, err := j.cloneRepoCommand(cancelCtx, ctx)
 	if err != nil {
 		return err
 	}
+	j.setCloneStrategy(r)
+	if err = j.checkShallowClone(); err != nil {
+		return err
+	}
+	if e := j.loadMailmap(ctx, r); e != nil {
+		j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Warningf("cannot load mailmap, identities are not canonicalized: %v", e)
+	}
 
 	firstCommit, err := j.getFirstCommit(ctx, r)
 	if err != nil {
 		return err
 	}
-	FirstCommitAt = firstCommit.Author.When
-	from := firstCommit.Author.When.Add(time.Second * -60)
+	j.state.firstCommitAt = firstCommit.Author.When
+	if prev := j.state.previousSync; prev != nil && j.state.cloneStrategy == GitCloneStrategyShallowSince {
+		// root commit of a shallow clone is its boundary commit, not the repository's first commit
+		j.state.firstCommitAt = prev.FirstCommitAt
+	}
+	from := j.state.firstCommitAt.Add(time.Second * -60)
 	if ctx.DateFrom.After(from) {
 		from = *ctx.DateFrom
 	}
@@ -3033,21 +3434,41 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 	if err != nil {
 		return err
 	}
-	if err = j.getCloc(ctx, j.headCommitHash); err != nil {
+	if err = j.getCloc(r, j.headCommitHash); err != nil {
 		return err
 	}
+	if err = j.checkCancelled(cancelCtx, "getCloc"); err != nil {
+		return
+	}
 	if thrN > 1 {
-		occh, _ = j.GetOrphanedCommits(ctx, thrN)
+		goch, _ = j.GetGitOps(ctx, r, thrN)
 	} else {
-		_, err = j.GetOrphanedCommits(ctx, thrN)
+		_, err = j.GetGitOps(ctx, r, thrN)
 		if err != nil {
 			return
 		}
+		if err = j.checkCancelled(cancelCtx, "GetGitOps"); err != nil {
+			return
+		}
+	}
+	if thrN > 1 {
+		occh, _ = j.GetOrphanedCommits(ctx, r, thrN)
+	} else {
+		_, err = j.GetOrphanedCommits(ctx, r, thrN)
+		if err != nil {
+			return
+		}
+		if err = j.checkCancelled(cancelCtx, "GetOrphanedCommits"); err != nil {
+			return
+		}
 	}
 	err = j.GetGitBranches(ctx)
 	if err != nil {
 		return
 	}
+	if err = j.checkCancelled(cancelCtx, "GetGitBranches"); err != nil {
+		return
+	}
 
 	sourceID := ""
 	if j.RepositorySource == "github" {
@@ -3138,7 +3559,14 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 					}
 				}()
 				// ee = SendToQueue(ctx, j, true, UUID, allCommits)
-				ee = j.GitEnrichItems(ctx, thrN, allCommits, &allDocs, false)
+				ee = j.GitEnrichItems(cancelCtx, ctx, thrN, allCommits, &allDocs, false)
+				if ee == ErrSyncCancelled {
+					// keep commits, they are flushed by the final call
+					if allCommitsMtx != nil {
+						allCommitsMtx.Unlock()
+					}
+					return
+				}
 				if ee != nil {
 					j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error %v sending %d commits to queue", ee, len(allCommits))
 				}
@@ -3172,10 +3600,10 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		return err
 	}
 	if commitsCount >= HotRepoCount {
-		IsHotRep = true
-		CurrentCacheYear = from.Year()
+		j.state.isHotRepo = true
+		j.state.currentCacheYear = from.Year()
 		if int(from.Month()) > 6 {
-			CurrentCacheYearHalf = YearSecondHalf
+			j.state.currentCacheYearHalf = YearSecondHalf
 		}
 		j.getYearHalfCache(lastSync)
 		j.getUpdateCache(lastSync)
@@ -3183,28 +3611,47 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		j.getCache(lastSync)
 	}
 
-	for from.Before(headCommit.Author.When) {
-		until := from.Add(24 * time.Hour * 30)
-		comms, er := getRepoCommits(r, from, until)
-		if er != nil {
-			err = er
+	lastCommitAt := headCommit.Author.When
+	if j.AllBranches {
+		lastCommitAt, err = getBranchesLastCommitDate(r, lastCommitAt)
+		if err != nil {
 			return
 		}
+	}
+	windows := commitWindows(from, lastCommitAt, ctx.DateTo)
+	err = walkCommitWindows(cancelCtx, r, j.AllBranches, windows, func(w commitWindow, comms []object.Commit, commitBranches map[string][]string) error {
+		j.state.checkpoint.startWindow(w.from)
 		if thrN > 1 {
 			for i := len(comms) - 1; i >= 0; i-- {
+				if syncCancelled(cancelCtx) {
+					break
+				}
+				sha := comms[i].Hash.String()
+				if j.state.checkpoint.isPublished(sha) {
+					continue
+				}
 				var c map[string]interface{}
-				c, err = j.BuildCommitMap(comms[i])
+				c, err = j.BuildCommitMap(cancelCtx, ctx, comms[i])
 				if err != nil {
+					if syncCancelled(cancelCtx) {
+						err = nil
+						break
+					}
 					return err
 				}
+				j.setCommitBranches(c, commitBranches)
+				j.state.checkpoint.processed(sha)
 				var (
 					e    error
 					esch chan error
 				)
 				esch, e = processCommit(ch, c)
+				if e == ErrSyncCancelled {
+					break
+				}
 				if e != nil {
 					j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("process error: %v", e)
-					return
+					return e
 				}
 				if esch != nil {
 					if eschaMtx != nil {
@@ -3219,7 +3666,7 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 				if nThreads == thrN {
 					err = <-ch
 					if err != nil {
-						return
+						return err
 					}
 					nThreads--
 				}
@@ -3228,26 +3675,45 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 				err = <-ch
 				nThreads--
 				if err != nil {
-					return
+					return err
 				}
 			}
 		} else {
 
 			for i := len(comms) - 1; i >= 0; i-- {
+				if syncCancelled(cancelCtx) {
+					break
+				}
+				sha := comms[i].Hash.String()
+				if j.state.checkpoint.isPublished(sha) {
+					continue
+				}
 				var com map[string]interface{}
-				com, err = j.BuildCommitMap(comms[i])
+				com, err = j.BuildCommitMap(cancelCtx, ctx, comms[i])
 				if err != nil {
+					if syncCancelled(cancelCtx) {
+						err = nil
+						break
+					}
 					return err
 				}
+				j.setCommitBranches(com, commitBranches)
+				j.state.checkpoint.processed(sha)
 				_, err = processCommit(nil, com)
+				if err == ErrSyncCancelled {
+					err = nil
+					break
+				}
 				if err != nil {
-					return
+					return err
 				}
 			}
 
 		}
-		from = until
-		until = until.Add(24 * time.Hour)
+		return nil
+	})
+	if err != nil {
+		return
 	}
 	// NOTE: lock needed
 	if eschaMtx != nil {
@@ -3255,6 +3721,9 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 	}
 	for _, esch := range escha {
 		err = <-esch
+		if err == ErrSyncCancelled {
+			err = nil
+		}
 		if err != nil {
 			if eschaMtx != nil {
 				eschaMtx.Unlock()
@@ -3272,11 +3741,31 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 	if ctx.Debug > 0 {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Debugf("%d remaining commits to send to queue", nCommits)
 	}
+	if syncCancelled(cancelCtx) {
+		j.logCancelled("SyncV2")
+	}
 	// NOTE: for all items, even if 0 - to flush the queue
 	// err = SendToQueue(ctx, j, true, UUID, allCommits)
-	err = j.GitEnrichItems(ctx, thrN, allCommits, &allDocs, true)
+	err = j.GitEnrichItems(cancelCtx, ctx, thrN, allCommits, &allDocs, true)
 	if err != nil {
 		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("Error %v sending %d commits to queue", err, len(allCommits))
+		if syncCancelled(cancelCtx) {
+			// maxUpstreamDt already includes commits that were not published, so last sync is not saved
+			// and the next run continues from the last checkpoint
+			return
+		}
+	}
+	if syncCancelled(cancelCtx) {
+		// tags, stats and data lake orphans are synced by the next run
+		err = j.setLastSync(ctx)
+		if err != nil {
+			return
+		}
+		if e := j.ReportDCO(ctx); e != nil {
+			j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Errorf("Error writing DCO report: %v", e)
+		}
+		err = ErrSyncCancelled
+		return
 	}
 	if !locFinished {
 		go func() {
@@ -3292,11 +3781,35 @@ func (j *DSGit) SyncV2(ctx *shared.Ctx) (err error) {
 		}()
 	}
 
+	if j.TagEvents {
+		err = j.SyncTags(ctx, r)
+		if err != nil {
+			j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Errorf("Error syncing tags: %v", err)
+			return
+		}
+	}
+
+	if j.StatsSnapshots && j.state.cloneStrategy == GitCloneStrategyBlobless {
+		j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Warning("WARNING: repository stats snapshots need file contents, skipped for blobless clone")
+	} else if j.StatsSnapshots {
+		err = j.SyncStats(ctx, r)
+		if err != nil {
+			j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Errorf("Error syncing repository stats: %v", err)
+			return
+		}
+	}
+
 	if lastSync != "" {
 		j.handleDataLakeOrphans()
 	}
 	// NOTE: Non-generic ends here
 	err = j.setLastSync(ctx)
+	if err != nil {
+		return
+	}
+	if e := j.ReportDCO(ctx); e != nil {
+		j.log.WithFields(logrus.Fields{"operation": "SyncV2"}).Errorf("Error writing DCO report: %v", e)
+	}
 	return
 }
 
@@ -3313,13 +3826,17 @@ func main() {
 	}
 	git.log = git.log.WithFields(
 		logrus.Fields{
-			"endpoint": git.URL,
+			"endpoint": RedactURL(git.URL),
 		})
 	timestamp := time.Now()
 	shared.SetSyncMode(true, false)
 	shared.SetLogLoggerError(false)
-	shared.AddLogger(&git.Logger, GitDataSource, logger.Internal, []map[string]string{{"REPO_URL": git.URL, "ProjectSlug": ctx.Project}})
-	git.AddCacheProvider()
+	shared.AddLogger(&git.Logger, GitDataSource, logger.Internal, []map[string]string{{"REPO_URL": RedactURL(git.URL), "ProjectSlug": ctx.Project}})
+	err = git.AddCacheProvider()
+	if err != nil {
+		git.log.WithFields(logrus.Fields{"operation": "main"}).Errorf("AddCacheProvider Error: %+v", err)
+		return
+	}
 	git.AddReportProvider()
 	/*	if os.Getenv("SPAN") != "" {
 		tracer.Start(tracer.WithGlobalTag("connector", "git"))
@@ -3349,7 +3866,25 @@ func main() {
 	if err = git.addAuth0Client(); err != nil {
 		git.log.WithFields(logrus.Fields{"operation": "main"}).Errorf("addAuth0Client Error : %+v", err)
 	}
-	err = git.SyncV2(&ctx)
+	cancelCtx, stop := signalContext()
+	defer stop()
+	if git.Manifest != "" {
+		var summary ManifestSummary
+		summary, err = git.SyncManifest(cancelCtx, &ctx)
+		if err == nil && summary.Failed > 0 {
+			err = fmt.Errorf("%d/%d manifest repositories failed to sync", summary.Failed, summary.Total)
+		}
+	} else {
+		err = git.SyncV2(cancelCtx, &ctx)
+	}
+	if err == ErrSyncCancelled {
+		git.log.WithFields(logrus.Fields{"operation": "main"}).Warning("sync cancelled, processed commits flushed")
+		er := git.WriteLog(&ctx, timestamp, Cancelled, err.Error())
+		if er != nil {
+			git.log.WithFields(logrus.Fields{"operation": "main"}).Errorf("WriteLog Error : %+v", er)
+		}
+		os.Exit(GitCancelledExitCode)
+	}
 	if err != nil {
 		git.log.WithFields(logrus.Fields{"operation": "main"}).Errorf("Error: %+v", err)
 		er := git.WriteLog(&ctx, timestamp, logger.Failed, err.Error())
@@ -3376,16 +3911,26 @@ func (j *DSGit) initStructuredLogger() {
 			"commit":      build.GitCommit,
 			"version":     build.Version,
 			"service":     build.AppName,
-			"endpoint":    j.URL,
+			"endpoint":    RedactURL(j.URL),
 		})
 	j.log = log
 }
 
 // AddCacheProvider - adds cache provider
-func (j *DSGit) AddCacheProvider() {
-	cacheProvider := cache.NewManager(fmt.Sprintf("v2/%s", GitDataSource), os.Getenv("STAGE"))
-	j.cacheProvider = *cacheProvider
+func (j *DSGit) AddCacheProvider() (err error) {
+	switch j.CacheBackend {
+	case CacheBackendLocal:
+		j.cacheProvider, err = NewLocalCache(j.CachePath)
+		if err != nil {
+			return
+		}
+	case CacheBackendMemory:
+		j.cacheProvider = NewMemoryCache()
+	default:
+		j.cacheProvider = cache.NewManager(fmt.Sprintf("v2/%s", GitDataSource), os.Getenv("STAGE"))
+	}
 	j.endpoint = strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(j.URL, "https://"), "git://"), "http://"), "/", "-")
+	return
 }
 
 // AddReportProvider - adds report provider
@@ -3395,111 +3940,37 @@ func (j *DSGit) AddReportProvider() {
 	j.endpoint = strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(j.URL, "https://"), "git://"), "http://"), "/", "-")
 }
 
-func (j *DSGit) createCacheFile(cache []CommitCache, path string) error {
-	for _, comm := range cache {
-		comm.FileLocation = path
-		cachedCommits[comm.EntityID] = comm
-	}
+// cacheFileCSV - encode cache entries in the CSV format read by getCacheFileByKey
+func cacheFileCSV(cache map[string]CommitCache) ([]byte, error) {
 	records := [][]string{
 		{"timestamp", "entity_id", "source_entity_id", "file_location", "hash", "orphaned", "from_dl", "content"},
 	}
-	for _, c := range cachedCommits {
+	for _, c := range cache {
 		records = append(records, []string{c.Timestamp, c.EntityID, c.SourceEntityID, c.FileLocation, c.Hash, strconv.FormatBool(c.Orphaned), strconv.FormatBool(c.FromDL), c.Content})
 	}
-
-	csvFile, err := os.Create(commitsCacheFile)
-	if err != nil {
-		return err
+	var file bytes.Buffer
+	w := csv.NewWriter(&file)
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
 	}
+	return file.Bytes(), nil
+}
 
-	w := csv.NewWriter(csvFile)
-	err = w.WriteAll(records)
-	if err != nil {
-		return err
-	}
-	err = csvFile.Close()
+// updateCacheFile - store cache entries as a CSV cache file
+func (j *DSGit) updateCacheFile(name string, cache map[string]CommitCache) error {
+	data, err := cacheFileCSV(cache)
 	if err != nil {
 		return err
 	}
-	file, err := os.ReadFile(commitsCacheFile)
-	if err != nil {
-		return err
-	}
-	err = os.Remove(commitsCacheFile)
-	if err != nil {
-		return err
-	}
-	err = j.cacheProvider.UpdateFileByKey(j.endpoint, commitsCacheFile, file)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return j.cacheProvider.UpdateFileByKey(j.endpoint, name, data)
 }
 
-func (j *DSGit) createYearHalfCacheFileOld(cache []CommitCache, path string) error {
-	nextYearHalfCache := make([]CommitCache, 0)
-	currentYearCommitsCount := 0
+func (j *DSGit) createCacheFile(cache []CommitCache, path string) error {
 	for _, comm := range cache {
 		comm.FileLocation = path
-		commitYearHalf := getDateYearHalf(comm.CommitDate)
-		if comm.CommitDate.Year() == CurrentCacheYear && commitYearHalf == CurrentCacheYearHalf {
-			cachedCommits[comm.EntityID] = comm
-			currentYearCommitsCount++
-		} else {
-			nextYearHalfCache = append(nextYearHalfCache, comm)
-		}
-	}
-	if currentYearCommitsCount == 0 {
-		return nil
-	}
-	records := [][]string{
-		{"timestamp", "entity_id", "source_entity_id", "file_location", "hash", "orphaned", "from_dl", "content"},
-	}
-	for _, c := range cachedCommits {
-		records = append(records, []string{c.Timestamp, c.EntityID, c.SourceEntityID, c.FileLocation, c.Hash, strconv.FormatBool(c.Orphaned), strconv.FormatBool(c.FromDL), c.Content})
-	}
-
-	yearSTR := strconv.Itoa(CurrentCacheYear)
-	cacheFile := fmt.Sprintf(CommitsByYearHalfCacheFile, yearSTR, CurrentCacheYearHalf)
-	csvFile, err := os.Create(cacheFile)
-	if err != nil {
-		return err
+		j.state.cachedCommits[comm.EntityID] = comm
 	}
-
-	w := csv.NewWriter(csvFile)
-	err = w.WriteAll(records)
-	if err != nil {
-		return err
-	}
-	err = csvFile.Close()
-	if err != nil {
-		return err
-	}
-	err = j.cacheProvider.UpdateMultiPartFileByKey(j.endpoint, cacheFile)
-	if err != nil {
-		return err
-	}
-
-	err = os.Remove(cacheFile)
-	if err != nil {
-		return err
-	}
-
-	if len(nextYearHalfCache) > 0 {
-		CurrentCacheYear = nextYearHalfCache[0].CommitDate.Year()
-		CurrentCacheYearHalf = YearFirstHalf
-		if nextYearHalfCache[0].CommitDate.Month() > 6 {
-			CurrentCacheYearHalf = YearSecondHalf
-		}
-		//updateYearHalf(nextYearHalfCache[0].CommitDate)
-		if err = j.createYearHalfCacheFile(nextYearHalfCache, path); err != nil {
-			return err
-		}
-		cachedCommits = make(map[string]CommitCache)
-		j.getYearHalfCache(os.Getenv("LAST_SYNC"))
-	}
-	return nil
+	return j.updateCacheFile(commitsCacheFile, j.state.cachedCommits)
 }
 
 func (j *DSGit) createYearHalfCacheFile(cache []CommitCache, path string) error {
@@ -3507,8 +3978,8 @@ func (j *DSGit) createYearHalfCacheFile(cache []CommitCache, path string) error
 	for _, comm := range cache {
 		comm.FileLocation = path
 		commitYearHalf := getDateYearHalf(comm.CommitDate)
-		if comm.CommitDate.Year() == CurrentCacheYear && commitYearHalf == CurrentCacheYearHalf {
-			cachedCommits[comm.EntityID] = comm
+		if comm.CommitDate.Year() == j.state.currentCacheYear && commitYearHalf == j.state.currentCacheYearHalf {
+			j.state.cachedCommits[comm.EntityID] = comm
 		} else {
 			nextYearHalfCache = append(nextYearHalfCache, comm)
 		}
@@ -3519,16 +3990,16 @@ func (j *DSGit) createYearHalfCacheFile(cache []CommitCache, path string) error
 	}
 
 	if len(nextYearHalfCache) > 0 {
-		CurrentCacheYear = nextYearHalfCache[0].CommitDate.Year()
-		CurrentCacheYearHalf = YearFirstHalf
+		j.state.currentCacheYear = nextYearHalfCache[0].CommitDate.Year()
+		j.state.currentCacheYearHalf = YearFirstHalf
 		if nextYearHalfCache[0].CommitDate.Month() > 6 {
-			CurrentCacheYearHalf = YearSecondHalf
+			j.state.currentCacheYearHalf = YearSecondHalf
 		}
 
 		j.getYearHalfCache(os.Getenv("LAST_SYNC"))
 		for _, comm := range nextYearHalfCache {
 			comm.FileLocation = path
-			cachedCommits[comm.EntityID] = comm
+			j.state.cachedCommits[comm.EntityID] = comm
 		}
 
 		if err := j.syncRemoteCurrentYearCache(); err != nil {
@@ -3540,40 +4011,22 @@ func (j *DSGit) createYearHalfCacheFile(cache []CommitCache, path string) error
 }
 
 func (j *DSGit) syncRemoteCurrentYearCache() error {
-	records := [][]string{
-		{"timestamp", "entity_id", "source_entity_id", "file_location", "hash", "orphaned", "from_dl", "content"},
-	}
-	for _, c := range cachedCommits {
-		records = append(records, []string{c.Timestamp, c.EntityID, c.SourceEntityID, c.FileLocation, c.Hash, strconv.FormatBool(c.Orphaned), strconv.FormatBool(c.FromDL), c.Content})
-	}
-
-	yearSTR := strconv.Itoa(CurrentCacheYear)
-	cacheFile := fmt.Sprintf(CommitsByYearHalfCacheFile, yearSTR, CurrentCacheYearHalf)
-	csvFile, err := os.Create(cacheFile)
-	if err != nil {
-		return err
-	}
-
-	w := csv.NewWriter(csvFile)
-	err = w.WriteAll(records)
-	if err != nil {
-		return err
-	}
-	err = csvFile.Close()
+	data, err := cacheFileCSV(j.state.cachedCommits)
 	if err != nil {
 		return err
 	}
-	err = j.cacheProvider.UpdateMultiPartFileByKey(j.endpoint, cacheFile)
+	yearSTR := strconv.Itoa(j.state.currentCacheYear)
+	// multi-part cache files are stored under their base name, so each sync writes into its own temporary directory
+	dir, err := os.MkdirTemp("", "git-cache-*")
 	if err != nil {
 		return err
 	}
-
-	err = os.Remove(cacheFile)
-	if err != nil {
+	defer func() { _ = os.RemoveAll(dir) }()
+	cacheFile := filepath.Join(dir, fmt.Sprintf(CommitsByYearHalfCacheFile, yearSTR, j.state.currentCacheYearHalf))
+	if err = os.WriteFile(cacheFile, data, 0644); err != nil {
 		return err
 	}
-
-	return nil
+	return j.cacheProvider.UpdateMultiPartFileByKey(j.endpoint, cacheFile)
 }
 
 func getDateYearHalf(commitDate time.Time) string {
@@ -3584,67 +4037,33 @@ func getDateYearHalf(commitDate time.Time) string {
 	return YearFirstHalf
 }
 
-func updateYearHalf(commitDate time.Time) {
+func (s *syncState) updateYearHalf(commitDate time.Time) {
 	cuHalf := getDateYearHalf(commitDate)
-	if cuHalf == CurrentCacheYearHalf {
+	if cuHalf == s.currentCacheYearHalf {
 		return
 	}
 
-	if CurrentCacheYearHalf == YearFirstHalf {
-		CurrentCacheYearHalf = YearSecondHalf
+	if s.currentCacheYearHalf == YearFirstHalf {
+		s.currentCacheYearHalf = YearSecondHalf
 		return
 	}
-	CurrentCacheYearHalf = YearFirstHalf
-	CurrentCacheYear += 1
+	s.currentCacheYearHalf = YearFirstHalf
+	s.currentCacheYear += 1
 }
 
 func (j *DSGit) createUpdateCacheFile(cache []CommitCache, path string) error {
 	for _, comm := range cache {
 		comm.FileLocation = path
-		CachedCommitsUpdates[comm.EntityID] = comm
-	}
-	records := [][]string{
-		{"timestamp", "entity_id", "source_entity_id", "file_location", "hash", "orphaned", "from_dl", "content"},
-	}
-	for _, c := range CachedCommitsUpdates {
-		records = append(records, []string{c.Timestamp, c.EntityID, c.SourceEntityID, c.FileLocation, c.Hash, strconv.FormatBool(c.Orphaned), strconv.FormatBool(c.FromDL), c.Content})
-	}
-
-	csvFile, err := os.Create(CommitsUpdateCacheFile)
-	if err != nil {
-		return err
-	}
-
-	w := csv.NewWriter(csvFile)
-	err = w.WriteAll(records)
-	if err != nil {
-		return err
-	}
-	err = csvFile.Close()
-	if err != nil {
-		return err
-	}
-	file, err := os.ReadFile(CommitsUpdateCacheFile)
-	if err != nil {
-		return err
-	}
-	err = os.Remove(CommitsUpdateCacheFile)
-	if err != nil {
-		return err
-	}
-	err = j.cacheProvider.UpdateFileByKey(j.endpoint, CommitsUpdateCacheFile, file)
-	if err != nil {
-		return err
+		j.state.cachedCommitsUpdates[comm.EntityID] = comm
 	}
-
-	return nil
+	return j.updateCacheFile(CommitsUpdateCacheFile, j.state.cachedCommitsUpdates)
 }
 
-func isHashCreated(hash string) bool {
-	c, ok := cachedCommits[hash]
+func (s *syncState) isHashCreated(hash string) bool {
+	c, ok := s.cachedCommits[hash]
 	if ok {
 		c.Orphaned = false
-		cachedCommits[hash] = c
+		s.cachedCommits[hash] = c
 		return true
 	}
 	return false
@@ -3687,7 +4106,7 @@ func (j *DSGit) getCache(lastSync string) {
 			}
 		}
 
-		cachedCommits[record[4]] = CommitCache{
+		j.state.cachedCommits[record[4]] = CommitCache{
 			Timestamp:      record[0],
 			EntityID:       record[1],
 			SourceEntityID: record[2],
@@ -3698,7 +4117,7 @@ func (j *DSGit) getCache(lastSync string) {
 			Content:        content,
 		}
 
-		createdCommits[record[1]] = true
+		j.state.createdCommits[record[1]] = true
 	}
 }
 
@@ -3739,7 +4158,7 @@ func (j *DSGit) getUpdateCache(lastSync string) {
 			}
 		}
 
-		CachedCommitsUpdates[record[4]] = CommitCache{
+		j.state.cachedCommitsUpdates[record[4]] = CommitCache{
 			Timestamp:      record[0],
 			EntityID:       record[1],
 			SourceEntityID: record[2],
@@ -3750,13 +4169,13 @@ func (j *DSGit) getUpdateCache(lastSync string) {
 			Content:        content,
 		}
 
-		createdCommits[record[1]] = true
+		j.state.createdCommits[record[1]] = true
 	}
 }
 
 func (j *DSGit) getYearHalfCache(lastSync string) {
-	yearSTR := strconv.Itoa(CurrentCacheYear)
-	commentBytes, err := j.cacheProvider.GetFileByKey(j.endpoint, fmt.Sprintf(CommitsByYearHalfCacheFile, yearSTR, CurrentCacheYearHalf))
+	yearSTR := strconv.Itoa(j.state.currentCacheYear)
+	commentBytes, err := j.cacheProvider.GetFileByKey(j.endpoint, fmt.Sprintf(CommitsByYearHalfCacheFile, yearSTR, j.state.currentCacheYearHalf))
 	if err != nil {
 		return
 	}
@@ -3792,7 +4211,7 @@ func (j *DSGit) getYearHalfCache(lastSync string) {
 			}
 		}
 
-		cachedCommits[record[4]] = CommitCache{
+		j.state.cachedCommits[record[4]] = CommitCache{
 			Timestamp:      record[0],
 			EntityID:       record[1],
 			SourceEntityID: record[2],
@@ -3803,7 +4222,7 @@ func (j *DSGit) getYearHalfCache(lastSync string) {
 			Content:        content,
 		}
 
-		createdCommits[record[1]] = true
+		j.state.createdCommits[record[1]] = true
 	}
 }
 
@@ -3859,8 +4278,8 @@ func (j *DSGit) getCacheFileByKey(key string, lastSync string) (map[string]Commi
 	return commits, nil
 }
 
-func isCommitCreated(id string) bool {
-	_, ok := cachedCommits[id]
+func (s *syncState) isCommitCreated(id string) bool {
+	_, ok := s.cachedCommits[id]
 	return ok
 }
 
@@ -3882,20 +4301,20 @@ func (j *DSGit) handleDataLakeOrphans() {
 		Source:           insights.Source(j.RepositorySource),
 	}
 
-	for _, v := range cachedCommits {
+	for _, v := range j.state.cachedCommits {
 		if v.Orphaned {
 			commitB, err := b64.StdEncoding.DecodeString(v.Content)
 			if err != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error decode datalake orphand commit: %+v", err)
 			}
-			var commit git.Commit
+			var commit Commit
 			err = jsoniter.Unmarshal(commitB, &commit)
 			if err != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error unmarshall datalake orphand commit: %+v", err)
 				continue
 			}
 			commit.Orphaned = true
-			commitEvent := git.CommitUpdatedEvent{
+			commitEvent := CommitUpdatedEvent{
 				CommitBaseEvent: commitBaseEvent,
 				BaseEvent:       baseEvent,
 				Payload:         commit,
@@ -3911,17 +4330,17 @@ func (j *DSGit) handleDataLakeOrphans() {
 			return
 		}
 		for _, c := range formattedData {
-			payload := c.(git.CommitUpdatedEvent).Payload
-			contentHash, er := createHash(payload)
+			payload := c.(CommitUpdatedEvent).Payload
+			contentHash, er := createHash(payload.Commit)
 			if er != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error hashing commit data: %+v", err)
 				continue
 			}
-			commit := cachedCommits[contentHash]
+			commit := j.state.cachedCommits[contentHash]
 			commit.FromDL = false
 			commit.FileLocation = path
 			commit.Content = ""
-			cachedCommits[contentHash] = commit
+			j.state.cachedCommits[contentHash] = commit
 		}
 		if err = j.createUpdateCacheFile([]CommitCache{}, ""); err != nil {
 			j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error updating commits cache: %+v", err)
@@ -3934,7 +4353,7 @@ func (j *DSGit) handleDataLakeOrphans() {
 
 // handleHotRepoDataLakeOrphans Update hot repository commits in DL with new orphaned status
 func (j *DSGit) handleHotRepoDataLakeOrphans() {
-	year := FirstCommitAt.Year()
+	year := j.state.firstCommitAt.Year()
 	half := YearFirstHalf
 	yearSTR := strconv.Itoa(year)
 
@@ -3942,10 +4361,10 @@ func (j *DSGit) handleHotRepoDataLakeOrphans() {
 	for {
 		commits, err := j.getCacheFileByKey(cacheFileName, "")
 		if err != nil {
-			if year > CurrentCacheYear {
+			if year > j.state.currentCacheYear {
 				break
 			}
-			if year == CurrentCacheYear && CurrentCacheYearHalf == YearFirstHalf && half == YearSecondHalf {
+			if year == j.state.currentCacheYear && j.state.currentCacheYearHalf == YearFirstHalf && half == YearSecondHalf {
 				break
 			}
 			continue
@@ -3967,17 +4386,17 @@ func (j *DSGit) handleHotRepoDataLakeOrphans() {
 				return
 			}
 			for _, c := range formattedData {
-				payload := c.(git.CommitUpdatedEvent).Payload
-				contentHash, er := createHash(payload)
+				payload := c.(CommitUpdatedEvent).Payload
+				contentHash, er := createHash(payload.Commit)
 				if er != nil {
 					j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error hashing commit data: %+v", err)
 					continue
 				}
-				commit := cachedCommits[contentHash]
+				commit := j.state.cachedCommits[contentHash]
 				commit.FromDL = false
 				commit.FileLocation = path
 				commit.Content = ""
-				cachedCommits[contentHash] = commit
+				j.state.cachedCommits[contentHash] = commit
 			}
 			if err = j.createUpdateCacheFile([]CommitCache{}, ""); err != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error updating commits cache: %+v", err)
@@ -4011,14 +4430,14 @@ func (j *DSGit) handleSingleCacheFile(commits map[string]CommitCache) []interfac
 			if err != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error decode datalake orphand commit: %+v", err)
 			}
-			var commit git.Commit
+			var commit Commit
 			err = jsoniter.Unmarshal(commitB, &commit)
 			if err != nil {
 				j.log.WithFields(logrus.Fields{"operation": "handleDataLakeOrphans"}).Errorf("error unmarshall datalake orphand commit: %+v", err)
 				continue
 			}
 			commit.Orphaned = true
-			commitEvent := git.CommitUpdatedEvent{
+			commitEvent := CommitUpdatedEvent{
 				CommitBaseEvent: commitBaseEvent,
 				BaseEvent:       baseEvent,
 				Payload:         commit,
@@ -4057,15 +4476,16 @@ func (j *DSGit) setLastSync(ctx *shared.Ctx) error {
 		j.log.WithFields(logrus.Fields{"operation": "getHead"}).Warningf("error getting repository head %v", err)
 	}
 
-	gMaxUpstreamDtMtx.Lock()
-	defer gMaxUpstreamDtMtx.Unlock()
+	j.state.maxUpstreamDtMtx.Lock()
+	defer j.state.maxUpstreamDtMtx.Unlock()
 
 	lastSyncData := lastSyncFile{
-		LastSync:      gMaxUpstreamDt,
+		LastSync:      j.state.maxUpstreamDt,
 		Target:        commitsCount,
-		Total:         len(createdCommits),
+		Total:         len(j.state.createdCommits),
 		Head:          commitID,
-		FirstCommitAt: FirstCommitAt,
+		FirstCommitAt: j.state.firstCommitAt,
+		Checkpoint:    j.state.checkpoint.checkpoint(),
 	}
 
 	lastSyncDataB, err := jsoniter.Marshal(lastSyncData)
@@ -4073,7 +4493,7 @@ func (j *DSGit) setLastSync(ctx *shared.Ctx) error {
 		return err
 	}
 
-	if !gMaxUpstreamDt.IsZero() {
+	if !j.state.maxUpstreamDt.IsZero() {
 		err = j.cacheProvider.SetLastSyncFile(j.endpoint, lastSyncDataB)
 		if err != nil {
 			return err
@@ -4084,6 +4504,9 @@ func (j *DSGit) setLastSync(ctx *shared.Ctx) error {
 }
 
 func (j *DSGit) getCommitsCount(ctx *shared.Ctx) (int, error) {
+	if prev := j.state.previousSync; prev != nil && j.state.cloneStrategy == GitCloneStrategyShallowSince {
+		return j.shallowCommitsCount(ctx, prev), nil
+	}
 	count := 0
 	cmdLine := []string{"git", "rev-list", "--count", j.DefaultBranch}
 	sout, serr, err := shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
@@ -4218,9 +4641,18 @@ func (j *DSGit) getGerritRepoSourceId() (string, error) {
 }
 
 func (j *DSGit) cloneRepo() (*goGit.Repository, error) {
+	tags := goGit.NoTags
+	if j.TagEvents {
+		tags = goGit.AllTags
+	}
+	auth, err := j.gitAuth()
+	if err != nil {
+		return nil, err
+	}
 	r, err := goGit.PlainClone(j.GitPath, false, &goGit.CloneOptions{
-		URL:      j.URL,
-		Tags:     goGit.NoTags,
+		URL:      j.cloneURL(),
+		Auth:     auth,
+		Tags:     tags,
 		Progress: os.Stdout,
 	})
 	if err != nil {
@@ -4230,8 +4662,8 @@ func (j *DSGit) cloneRepo() (*goGit.Repository, error) {
 	return r, nil
 }
 
-func (j *DSGit) cloneRepoCommand(ctx *shared.Ctx) (*goGit.Repository, error) {
-	if err := j.CreateGitRepo(ctx); err != nil {
+func (j *DSGit) cloneRepoCommand(cancelCtx context.Context, ctx *shared.Ctx) (*goGit.Repository, error) {
+	if err := j.CreateGitRepo(cancelCtx, ctx); err != nil {
 		fmt.Println(err)
 		return nil, err
 	}
@@ -4255,65 +4687,247 @@ func (j *DSGit) cloneRepoCommand(ctx *shared.Ctx) (*goGit.Repository, error) {
 	return r, nil
 }
 
-func getRepoCommits(r *goGit.Repository, since time.Time, until time.Time) ([]object.Commit, error) {
-	ref, err := r.Head()
+// commitWindow - committer date range of commits processed and checkpointed together
+type commitWindow struct {
+	from  time.Time
+	until time.Time
+}
+
+// commitWindows - split committer dates from a given date till the last commit into 30-day windows, oldest first,
+// nothing after dateTo is walked when it is set
+func commitWindows(from, lastCommitAt time.Time, dateTo *time.Time) []commitWindow {
+	if dateTo != nil && dateTo.Before(lastCommitAt) {
+		lastCommitAt = *dateTo
+	}
+	windows := []commitWindow{}
+	for from.Before(lastCommitAt) {
+		until := from.Add(24 * time.Hour * 30)
+		if dateTo != nil && until.After(*dateTo) {
+			until = *dateTo
+		}
+		windows = append(windows, commitWindow{from: from, until: until})
+		from = until
+	}
+	return windows
+}
+
+// walkCommitWindows - call fn with each window's commits sorted by committer date descending,
+// and with branch names each commit is reachable from when walking all branches
+// history is walked once for all windows, a commit committed exactly at the windows' boundary belongs to the older one
+func walkCommitWindows(cancelCtx context.Context, r *goGit.Repository, allBranches bool, windows []commitWindow, fn func(w commitWindow, comms []object.Commit, commitBranches map[string][]string) error) error {
+	if len(windows) == 0 {
+		return nil
+	}
+	idx, err := newCommitIndex(cancelCtx, r, allBranches, windows[0].from)
 	if err != nil {
-		return []object.Commit{}, err
-	}
-	cIter, err := r.Log(&goGit.LogOptions{
-		From: ref.Hash(),
-		// logOrder 4 is to sort commits desc order by commiter date
-		Order: goGit.LogOrder(4),
-		Since: &since,
-		Until: &until,
+		return err
+	}
+	for _, w := range windows {
+		if syncCancelled(cancelCtx) {
+			return nil
+		}
+		comms, err := idx.window(w)
+		if err != nil {
+			return err
+		}
+		if err = fn(w, comms, idx.commitBranches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitIndex - hashes and committer dates of commits reachable from HEAD or from all branches, collected by a single
+// history walk per sync, commit objects are loaded window by window
+type commitIndex struct {
+	r              *goGit.Repository
+	commits        []indexedCommit     // sorted by committer date ascending
+	next           int                 // first commit not yet returned by window
+	commitBranches map[string][]string // branch names each commit is reachable from, only when walking all branches
+}
+
+type indexedCommit struct {
+	hash plumbing.Hash
+	when time.Time
+}
+
+// newCommitIndex - walk HEAD or all branch heads once by committer date descending, each walk stops at the first commit
+// committed before since, commits reachable from more than one branch are indexed once
+func newCommitIndex(cancelCtx context.Context, r *goGit.Repository, allBranches bool, since time.Time) (*commitIndex, error) {
+	idx := &commitIndex{r: r}
+	heads := make(map[string]plumbing.Hash)
+	if allBranches {
+		idx.commitBranches = make(map[string][]string)
+		refs, err := r.Branches()
+		if err != nil {
+			return nil, err
+		}
+		err = refs.ForEach(func(ref *plumbing.Reference) error {
+			heads[ref.Name().Short()] = ref.Hash()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ref, err := r.Head()
+		if err != nil {
+			return nil, err
+		}
+		heads[""] = ref.Hash()
+	}
+	// walk branches in a stable order, so commits with equal committer dates are always indexed the same way
+	branches := make([]string, 0, len(heads))
+	for branch := range heads {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		cIter, err := r.Log(&goGit.LogOptions{From: heads[branch], Order: goGit.LogOrderCommitterTime})
+		if err != nil {
+			return nil, err
+		}
+		err = cIter.ForEach(func(c *object.Commit) error {
+			if syncCancelled(cancelCtx) || c.Committer.When.Before(since) {
+				return storer.ErrStop
+			}
+			if idx.commitBranches == nil {
+				idx.commits = append(idx.commits, indexedCommit{hash: c.Hash, when: c.Committer.When})
+				return nil
+			}
+			sha := c.Hash.String()
+			if _, ok := idx.commitBranches[sha]; !ok {
+				idx.commits = append(idx.commits, indexedCommit{hash: c.Hash, when: c.Committer.When})
+			}
+			idx.commitBranches[sha] = append(idx.commitBranches[sha], branch)
+			return nil
+		})
+		// history of a shallow clone ends at its boundary commits, their parents are missing
+		if err != nil && !isMissingObject(err) {
+			return nil, err
+		}
+	}
+	sort.SliceStable(idx.commits, func(i, k int) bool {
+		return idx.commits[i].when.Before(idx.commits[k].when)
 	})
+	return idx, nil
+}
 
-	commits := make([]object.Commit, 0)
-	err = cIter.ForEach(func(c *object.Commit) error {
+// window - return commits committed within a window and not returned for any previous window,
+// sorted by committer date descending, windows must be requested oldest first
+func (idx *commitIndex) window(w commitWindow) ([]object.Commit, error) {
+	for idx.next < len(idx.commits) && idx.commits[idx.next].when.Before(w.from) {
+		idx.next++
+	}
+	start := idx.next
+	for idx.next < len(idx.commits) && !idx.commits[idx.next].when.After(w.until) {
+		idx.next++
+	}
+	commits := make([]object.Commit, 0, idx.next-start)
+	for i := idx.next - 1; i >= start; i-- {
+		c, err := idx.r.CommitObject(idx.commits[i].hash)
+		if err != nil {
+			return nil, err
+		}
 		commits = append(commits, *c)
-		return nil
-	})
+	}
 	return commits, nil
 }
 
-func (j *DSGit) getFirstCommit(ctx *shared.Ctx, repo *goGit.Repository) (*object.Commit, error) {
-	//git log --pretty=oneline --reverse | head -1
-	cmd := fmt.Sprintf("cd %s; git log --pretty=oneline --reverse | head -1", j.GitPath)
-	output, err := exec.Command("bash", "-c", cmd).Output()
+// getBranchesLastCommitDate - return the most recent committer date of all branch heads, but not earlier than a given date
+func getBranchesLastCommitDate(r *goGit.Repository, dt time.Time) (time.Time, error) {
+	refs, err := r.Branches()
 	if err != nil {
-		return nil, err
+		return dt, err
 	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		c, err := r.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+		if c.Committer.When.After(dt) {
+			dt = c.Committer.When
+		}
+		return nil
+	})
+	return dt, err
+}
 
-	fCommit := strings.Split(strings.TrimSpace(string(output)), " ")
-	firstCommit, err := repo.CommitObject(plumbing.NewHash(fCommit[0]))
-	if err != nil {
-		return firstCommit, err
+// setCommitBranches - set all branches a commit is reachable from, prefer default branch as the commit's branch
+func (j *DSGit) setCommitBranches(commit map[string]interface{}, commitBranches map[string][]string) {
+	if commitBranches == nil {
+		return
+	}
+	sha, _ := commit["commit"].(string)
+	branches := commitBranches[sha]
+	if len(branches) == 0 {
+		return
+	}
+	sort.Strings(branches)
+	commit["branches"] = branches
+	commit["branch"] = branches[0]
+	for _, branch := range branches {
+		if branch == j.DefaultBranch {
+			commit["branch"] = branch
+			break
+		}
 	}
-	return firstCommit, nil
 }
 
-func (j *DSGit) getCloc(ctx *shared.Ctx, headSha string) error {
-	cmdLine := []string{"cloc", "commit", headSha, "--json"}
+// getFirstCommit - return the oldest root commit reachable from HEAD
+func (j *DSGit) getFirstCommit(ctx *shared.Ctx, repo *goGit.Repository) (*object.Commit, error) {
+	cmdLine := []string{"git", "rev-list", "--max-parents=0", "HEAD"}
 	sout, serr, err := shared.ExecCommand(ctx, cmdLine, j.GitPath, GitDefaultEnv)
 	if err != nil {
-		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error executing command: %v, error: %v, output: %s, output error: %s", cmdLine, err, sout, serr)
-		return err
+		j.log.WithFields(logrus.Fields{"operation": "getFirstCommit"}).Errorf("error executing command: %v, error: %v, output: %s, output error: %s", cmdLine, err, sout, serr)
+		return nil, err
+	}
+	var firstCommit *object.Commit
+	for _, sha := range strings.Fields(sout) {
+		commit, err := repo.CommitObject(plumbing.NewHash(sha))
+		if err != nil {
+			return nil, err
+		}
+		if firstCommit == nil || commit.Author.When.Before(firstCommit.Author.When) {
+			firstCommit = commit
+		}
+	}
+	if firstCommit == nil {
+		return nil, fmt.Errorf("no root commit found in %s", j.GitPath)
 	}
+	return firstCommit, nil
+}
 
-	if sout == "" {
+// getCloc - count HEAD lines of code, reported as cloc_count of the HEAD commit
+func (j *DSGit) getCloc(r *goGit.Repository, headSha string) error {
+	res, err := j.countHeadLoc(r, headSha)
+	if err != nil && j.isPartialClone() && isMissingObject(err) {
+		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Warningf("WARNING: %s clone, HEAD lines of code not counted: %v", j.state.cloneStrategy, err)
 		return nil
 	}
-
-	r := make(map[string]clocResult)
-	err = jsoniter.Unmarshal([]byte(sout), &r)
 	if err != nil {
-		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error unmarshall: %v, error: %v", sout, err)
+		j.log.WithFields(logrus.Fields{"operation": "Sync"}).Errorf("error counting lines of code of %s: %v", headSha, err)
 		return err
 	}
-	j.headLinesOfCode = r["SUM"].Code
+	j.headLinesOfCode = res[LocSumKey].Code
 	return nil
 }
 
+// countHeadLoc - count lines of code of HEAD commit, the result is reused by getCloc and GetGitOps of the same sync
+func (j *DSGit) countHeadLoc(r *goGit.Repository, headSha string) (map[string]clocResult, error) {
+	if j.state != nil && j.state.headLocSHA == headSha && j.state.headLoc != nil {
+		return j.state.headLoc, nil
+	}
+	res, err := newLocCounter().CountCommit(r, headSha)
+	if err != nil {
+		return nil, err
+	}
+	if j.state != nil {
+		j.state.headLoc, j.state.headLocSHA = res, headSha
+	}
+	return res, nil
+}
+
 // CommitCache single commit cache schema
 type CommitCache struct {
 	Timestamp      string `json:"timestamp"`
@@ -4337,6 +4951,10 @@ type ReportData struct {
 	Date            int64  `json:"date"`
 	SyncStatus      string `json:"sync_status"`
 	OrphanedCommits int64  `json:"orphaned_commits"`
+	// DCO summary, only set in end-of-sync DCO report
+	DCOCommits             int64            `json:"dco_commits,omitempty"`
+	DCONonCompliantCommits int64            `json:"dco_non_compliant_commits,omitempty"`
+	DCONonCompliantAuthors map[string]int64 `json:"dco_non_compliant_authors,omitempty"`
 }
 
 type clocResult struct {
@@ -4346,12 +4964,33 @@ type clocResult struct {
 	NumberOfFiles int `json:"nFiles"`
 }
 
+// getLastSyncData - read last sync file, older syncs stored only the last sync date
+func (j *DSGit) getLastSyncData() (lastSyncData lastSyncFile, err error) {
+	lastSyncDataB, err := j.cacheProvider.GetLastSyncFile(j.endpoint)
+	if err != nil {
+		return
+	}
+	if er := jsoniter.Unmarshal(lastSyncDataB, &lastSyncData); er != nil {
+		var cachedLastSync time.Time
+		err = jsoniter.Unmarshal(lastSyncDataB, &cachedLastSync)
+		if err != nil {
+			err = er
+			return
+		}
+		lastSyncData = lastSyncFile{
+			LastSync: cachedLastSync,
+		}
+	}
+	return
+}
+
 type lastSyncFile struct {
-	LastSync      time.Time `json:"last_sync"`
-	Target        int       `json:"target,omitempty"`
-	Total         int       `json:"total,omitempty"`
-	Head          string    `json:"head,omitempty"`
-	FirstCommitAt time.Time `json:"first_commit_At"`
+	LastSync      time.Time       `json:"last_sync"`
+	Target        int             `json:"target,omitempty"`
+	Total         int             `json:"total,omitempty"`
+	Head          string          `json:"head,omitempty"`
+	FirstCommitAt time.Time       `json:"first_commit_At"`
+	Checkpoint    *syncCheckpoint `json:"checkpoint,omitempty"`
 }
 
 // CommitHashFields elected fields from commit schema to hash
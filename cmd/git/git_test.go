@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCommitWindows(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2022, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	dt := func(month time.Month, d int) *time.Time {
+		v := day(month, d)
+		return &v
+	}
+	var testCases = []struct {
+		name         string
+		from         time.Time
+		lastCommitAt time.Time
+		dateTo       *time.Time
+		expected     []commitWindow
+	}{
+		{
+			name:         "no date to",
+			from:         day(1, 1),
+			lastCommitAt: day(3, 15),
+			expected: []commitWindow{
+				{from: day(1, 1), until: day(1, 31)},
+				{from: day(1, 31), until: day(3, 2)},
+				{from: day(3, 2), until: day(4, 1)},
+			},
+		},
+		{
+			name:         "date to before last commit",
+			from:         day(1, 1),
+			lastCommitAt: day(3, 15),
+			dateTo:       dt(2, 10),
+			expected: []commitWindow{
+				{from: day(1, 1), until: day(1, 31)},
+				{from: day(1, 31), until: day(2, 10)},
+			},
+		},
+		{
+			name:         "date to after last commit caps the last window",
+			from:         day(1, 1),
+			lastCommitAt: day(1, 20),
+			dateTo:       dt(1, 25),
+			expected:     []commitWindow{{from: day(1, 1), until: day(1, 25)}},
+		},
+		{
+			name:         "date to after the last window",
+			from:         day(1, 1),
+			lastCommitAt: day(1, 20),
+			dateTo:       dt(6, 1),
+			expected:     []commitWindow{{from: day(1, 1), until: day(1, 31)}},
+		},
+		{
+			name:         "date to before date from",
+			from:         day(3, 1),
+			lastCommitAt: day(3, 15),
+			dateTo:       dt(2, 1),
+			expected:     []commitWindow{},
+		},
+		{
+			name:         "nothing after date from",
+			from:         day(3, 15),
+			lastCommitAt: day(3, 1),
+			expected:     []commitWindow{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := commitWindows(tc.from, tc.lastCommitAt, tc.dateTo)
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWalkCommitWindows(t *testing.T) {
+	at := func(month time.Month, d int) time.Time {
+		return time.Date(2022, month, d, 10, 0, 0, 0, time.UTC)
+	}
+	dateTo := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name        string
+		allBranches bool
+		dateTo      *time.Time
+		expected    []string
+	}{
+		{
+			name:     "head",
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "head till date to",
+			dateTo:   &dateTo,
+			expected: []string{"a", "b"},
+		},
+		{
+			name:        "all branches",
+			allBranches: true,
+			expected:    []string{"a", "b", "e", "c", "d"},
+		},
+		{
+			name:        "all branches till date to",
+			allBranches: true,
+			dateTo:      &dateTo,
+			expected:    []string{"a", "b", "e"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestRepo(t)
+			a := tr.commitAt("a", at(1, 1))
+			b := tr.commitAt("b", at(2, 15), a)
+			c := tr.commitAt("c", at(3, 20), b)
+			d := tr.commitAt("d", at(4, 10), c)
+			e := tr.commitAt("e", at(2, 20), b)
+			tr.setRef("refs/heads/master", d)
+			tr.setRef("refs/heads/feature", e)
+
+			windows := commitWindows(at(1, 1).Add(-time.Minute), at(4, 10), tc.dateTo)
+			got := []string{}
+			err := walkCommitWindows(context.Background(), tr.r, tc.allBranches, windows, func(w commitWindow, comms []object.Commit, commitBranches map[string][]string) error {
+				if tc.allBranches != (commitBranches != nil) {
+					t.Errorf("expected branches only when walking all branches, got %v", commitBranches)
+				}
+				// commits come newest first, the sync processes them oldest first
+				for i := len(comms) - 1; i >= 0; i-- {
+					when := comms[i].Committer.When
+					if when.Before(w.from) || when.After(w.until) {
+						t.Errorf("commit %s at %v outside of window %v - %v", comms[i].Message, when, w.from, w.until)
+					}
+					if tc.dateTo != nil && when.After(*tc.dateTo) {
+						t.Errorf("commit %s at %v after date to %v", comms[i].Message, when, *tc.dateTo)
+					}
+					got = append(got, comms[i].Message)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommitIndex(t *testing.T) {
+	at := func(month time.Month, d int) time.Time {
+		return time.Date(2022, month, d, 10, 0, 0, 0, time.UTC)
+	}
+	tr := newTestRepo(t)
+	a := tr.commitAt("a", at(1, 1))
+	b := tr.commitAt("b", at(1, 31), a)
+	c := tr.commitAt("c", at(2, 10), b)
+	e := tr.commitAt("e", at(2, 5), b)
+	m := tr.commitAt("m", at(2, 20), c, e)
+	tr.setRef("refs/heads/master", m)
+	tr.setRef("refs/heads/feature", e)
+	tr.setRef("refs/heads/release", c)
+
+	idx, err := newCommitIndex(context.Background(), tr.r, true, at(1, 15))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedBranches := map[string][]string{
+		b.String(): {"feature", "master", "release"},
+		e.String(): {"feature", "master"},
+		c.String(): {"master", "release"},
+		m.String(): {"master"},
+	}
+	if !reflect.DeepEqual(expectedBranches, idx.commitBranches) {
+		t.Errorf("expected branches %v, got %v", expectedBranches, idx.commitBranches)
+	}
+	var testCases = []struct {
+		name     string
+		window   commitWindow
+		expected []string
+	}{
+		{
+			name:     "commit at the window's end belongs to it",
+			window:   commitWindow{from: at(1, 1), until: at(1, 31)},
+			expected: []string{"b"},
+		},
+		{
+			name:     "commit at the window's start was returned already",
+			window:   commitWindow{from: at(1, 31), until: at(2, 10)},
+			expected: []string{"c", "e"},
+		},
+		{
+			name:     "commits reachable from many branches returned once",
+			window:   commitWindow{from: at(2, 10), until: at(3, 1)},
+			expected: []string{"m"},
+		},
+		{
+			name:     "nothing left",
+			window:   commitWindow{from: at(3, 1), until: at(4, 1)},
+			expected: []string{},
+		},
+	}
+	for _, tc := range testCases {
+		comms, err := idx.window(tc.window)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		got := []string{}
+		for _, c := range comms {
+			got = append(got, c.Message)
+		}
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+
+	idx, err = newCommitIndex(context.Background(), tr.r, false, at(1, 15))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if idx.commitBranches != nil {
+		t.Errorf("expected no branches walking HEAD, got %v", idx.commitBranches)
+	}
+	if len(idx.commits) != 4 {
+		t.Errorf("expected 4 commits since date from, got %d", len(idx.commits))
+	}
+}
+
+func TestSetCommitBranches(t *testing.T) {
+	var testCases = []struct {
+		name           string
+		sha            string
+		commitBranches map[string][]string
+		branch         interface{}
+		branches       interface{}
+	}{
+		{
+			name:           "default branch preferred",
+			sha:            "a",
+			commitBranches: map[string][]string{"a": {"release", "main", "feature"}},
+			branch:         "main",
+			branches:       []string{"feature", "main", "release"},
+		},
+		{
+			name:           "first branch when not on default branch",
+			sha:            "a",
+			commitBranches: map[string][]string{"a": {"release", "feature"}},
+			branch:         "feature",
+			branches:       []string{"feature", "release"},
+		},
+		{
+			name:           "commit not on any branch",
+			sha:            "b",
+			commitBranches: map[string][]string{"a": {"main"}},
+		},
+		{
+			name: "not walking all branches",
+			sha:  "a",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			j := &DSGit{DefaultBranch: "main"}
+			commit := map[string]interface{}{"commit": tc.sha}
+			j.setCommitBranches(commit, tc.commitBranches)
+			if !reflect.DeepEqual(tc.branch, commit["branch"]) {
+				t.Errorf("expected branch %v, got %v", tc.branch, commit["branch"])
+			}
+			if !reflect.DeepEqual(tc.branches, commit["branches"]) {
+				t.Errorf("expected branches %v, got %v", tc.branches, commit["branches"])
+			}
+		})
+	}
+}
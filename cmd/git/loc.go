@@ -0,0 +1,365 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	// LocOtherLanguage - language reported for files not recognized by DetectLanguage
+	LocOtherLanguage = "Other"
+	// LocSumKey - key of the all languages total in cloc-like results
+	LocSumKey = "SUM"
+	// LocMaxFileSize - files larger than this are assumed to be generated/data files and are not counted
+	LocMaxFileSize = 8 << 20
+)
+
+// locLanguage - language definition used by the line counter
+type locLanguage struct {
+	Name          string
+	LineComments  []string
+	BlockComments [][2]string
+}
+
+var (
+	locC      = []string{"//"}
+	locCBlock = [][2]string{{"/*", "*/"}}
+	locHash   = []string{"#"}
+	locXML    = [][2]string{{"<!--", "-->"}}
+	locSQL    = []string{"--"}
+	// locLanguages - languages by lower case file extension, names follow cloc naming
+	locLanguages = map[string]locLanguage{
+		".go":         {"Go", locC, locCBlock},
+		".c":          {"C", locC, locCBlock},
+		".h":          {"C/C++ Header", locC, locCBlock},
+		".hh":         {"C/C++ Header", locC, locCBlock},
+		".hpp":        {"C/C++ Header", locC, locCBlock},
+		".cc":         {"C++", locC, locCBlock},
+		".cpp":        {"C++", locC, locCBlock},
+		".cxx":        {"C++", locC, locCBlock},
+		".cs":         {"C#", locC, locCBlock},
+		".java":       {"Java", locC, locCBlock},
+		".kt":         {"Kotlin", locC, locCBlock},
+		".kts":        {"Kotlin", locC, locCBlock},
+		".scala":      {"Scala", locC, locCBlock},
+		".groovy":     {"Groovy", locC, locCBlock},
+		".gradle":     {"Gradle", locC, locCBlock},
+		".swift":      {"Swift", locC, locCBlock},
+		".m":          {"Objective-C", locC, locCBlock},
+		".mm":         {"Objective-C++", locC, locCBlock},
+		".rs":         {"Rust", locC, locCBlock},
+		".js":         {"JavaScript", locC, locCBlock},
+		".mjs":        {"JavaScript", locC, locCBlock},
+		".cjs":        {"JavaScript", locC, locCBlock},
+		".jsx":        {"JSX", locC, locCBlock},
+		".ts":         {"TypeScript", locC, locCBlock},
+		".tsx":        {"TypeScript", locC, locCBlock},
+		".dart":       {"Dart", locC, locCBlock},
+		".php":        {"PHP", []string{"//", "#"}, locCBlock},
+		".css":        {"CSS", nil, locCBlock},
+		".scss":       {"SCSS", locC, locCBlock},
+		".less":       {"LESS", locC, locCBlock},
+		".proto":      {"Protocol Buffers", locC, locCBlock},
+		".sol":        {"Solidity", locC, locCBlock},
+		".zig":        {"Zig", locC, nil},
+		".py":         {"Python", locHash, [][2]string{{`"""`, `"""`}, {`'''`, `'''`}}},
+		".pyx":        {"Cython", locHash, [][2]string{{`"""`, `"""`}}},
+		".rb":         {"Ruby", locHash, [][2]string{{"=begin", "=end"}}},
+		".pl":         {"Perl", locHash, [][2]string{{"=pod", "=cut"}}},
+		".pm":         {"Perl", locHash, [][2]string{{"=pod", "=cut"}}},
+		".sh":         {"Bourne Shell", locHash, nil},
+		".bash":       {"Bourne Again Shell", locHash, nil},
+		".zsh":        {"zsh", locHash, nil},
+		".ps1":        {"PowerShell", locHash, [][2]string{{"<#", "#>"}}},
+		".r":          {"R", locHash, nil},
+		".jl":         {"Julia", locHash, [][2]string{{"#=", "=#"}}},
+		".ex":         {"Elixir", locHash, nil},
+		".exs":        {"Elixir", locHash, nil},
+		".erl":        {"Erlang", []string{"%"}, nil},
+		".hrl":        {"Erlang", []string{"%"}, nil},
+		".hs":         {"Haskell", []string{"--"}, [][2]string{{"{-", "-}"}}},
+		".ml":         {"OCaml", nil, [][2]string{{"(*", "*)"}}},
+		".clj":        {"Clojure", []string{";"}, nil},
+		".cljs":       {"ClojureScript", []string{";"}, nil},
+		".el":         {"Lisp", []string{";"}, nil},
+		".lua":        {"Lua", []string{"--"}, [][2]string{{"--[[", "]]"}}},
+		".sql":        {"SQL", locSQL, locCBlock},
+		".tf":         {"HCL", []string{"#", "//"}, locCBlock},
+		".hcl":        {"HCL", []string{"#", "//"}, locCBlock},
+		".yaml":       {"YAML", locHash, nil},
+		".yml":        {"YAML", locHash, nil},
+		".toml":       {"TOML", locHash, nil},
+		".ini":        {"INI", []string{";", "#"}, nil},
+		".json":       {"JSON", nil, nil},
+		".xml":        {"XML", nil, locXML},
+		".xsd":        {"XSD", nil, locXML},
+		".html":       {"HTML", nil, locXML},
+		".htm":        {"HTML", nil, locXML},
+		".vue":        {"Vuejs Component", locC, [][2]string{{"<!--", "-->"}, {"/*", "*/"}}},
+		".svelte":     {"Svelte", locC, [][2]string{{"<!--", "-->"}, {"/*", "*/"}}},
+		".md":         {"Markdown", nil, locXML},
+		".markdown":   {"Markdown", nil, locXML},
+		".rst":        {"reStructuredText", nil, nil},
+		".tex":        {"TeX", []string{"%"}, nil},
+		".cmake":      {"CMake", locHash, nil},
+		".mk":         {"make", locHash, nil},
+		".bat":        {"DOS Batch", []string{"REM", "rem", "::"}, nil},
+		".vim":        {"vim script", []string{`"`}, nil},
+		".fs":         {"F#", locC, [][2]string{{"(*", "*)"}}},
+		".vb":         {"Visual Basic", []string{"'"}, nil},
+		".asm":        {"Assembly", []string{";"}, nil},
+		".s":          {"Assembly", []string{";", "#"}, nil},
+		".thrift":     {"Thrift", []string{"//", "#"}, locCBlock},
+		".graphql":    {"GraphQL", locHash, nil},
+		".dockerfile": {"Dockerfile", locHash, nil},
+	}
+	// locFileNames - languages by exact file name for files without meaningful extension
+	locFileNames = map[string]locLanguage{
+		"Makefile":       {"make", locHash, nil},
+		"makefile":       {"make", locHash, nil},
+		"GNUmakefile":    {"make", locHash, nil},
+		"Dockerfile":     {"Dockerfile", locHash, nil},
+		"CMakeLists.txt": {"CMake", locHash, nil},
+		"Rakefile":       {"Ruby", locHash, nil},
+		"Gemfile":        {"Ruby", locHash, nil},
+		"BUILD":          {"Bazel", locHash, nil},
+		"BUILD.bazel":    {"Bazel", locHash, nil},
+		"WORKSPACE":      {"Bazel", locHash, nil},
+	}
+)
+
+// DetectLanguage - return language name for a file path, empty string when language is not recognized
+func DetectLanguage(filePath string) string {
+	lang, ok := detectLocLanguage(filePath)
+	if !ok {
+		return ""
+	}
+	return lang.Name
+}
+
+// detectLocLanguage - return language definition for a file path
+func detectLocLanguage(filePath string) (locLanguage, bool) {
+	base := path.Base(filePath)
+	if lang, ok := locFileNames[base]; ok {
+		return lang, true
+	}
+	if strings.HasPrefix(base, "Dockerfile.") {
+		return locFileNames["Dockerfile"], true
+	}
+	lang, ok := locLanguages[strings.ToLower(path.Ext(base))]
+	return lang, ok
+}
+
+// countLines - count code, comment and blank lines of a file's contents, the same way cloc does:
+// lines containing both code and comment are counted as code
+func countLines(contents string, lang locLanguage) (res clocResult) {
+	res.NumberOfFiles = 1
+	if contents == "" {
+		return
+	}
+	contents = strings.TrimSuffix(contents, "\n")
+	var blockEnd string
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			res.Blank++
+			continue
+		}
+		if blockEnd != "" {
+			res.Comment++
+			if i := strings.Index(line, blockEnd); i >= 0 {
+				rest := strings.TrimSpace(line[i+len(blockEnd):])
+				blockEnd = lang.openBlock(rest)
+				if rest != "" && !lang.isComment(rest) {
+					res.Comment--
+					res.Code++
+				}
+			}
+			continue
+		}
+		if lang.isLineComment(line) {
+			res.Comment++
+			continue
+		}
+		if start, end, ok := lang.blockStart(line); ok {
+			rest := line[len(start):]
+			i := strings.Index(rest, end)
+			if i < 0 {
+				blockEnd = end
+				res.Comment++
+				continue
+			}
+			rest = strings.TrimSpace(rest[i+len(end):])
+			if rest == "" || lang.isComment(rest) {
+				res.Comment++
+			} else {
+				res.Code++
+			}
+			blockEnd = lang.openBlock(rest)
+			continue
+		}
+		res.Code++
+		// code line opening a block comment that continues on next lines
+		blockEnd = lang.openBlock(line)
+	}
+	return
+}
+
+// openBlock - return closing marker of a block comment left open at the end of line, empty when there is none
+// markers are matched left to right, so identical opening and closing markers (Python docstrings) pair correctly
+func (l locLanguage) openBlock(line string) string {
+	for {
+		start, end, i := "", "", -1
+		for _, block := range l.BlockComments {
+			if k := strings.Index(line, block[0]); k >= 0 && (i < 0 || k < i) {
+				start, end, i = block[0], block[1], k
+			}
+		}
+		if i < 0 {
+			return ""
+		}
+		line = line[i+len(start):]
+		k := strings.Index(line, end)
+		if k < 0 {
+			return end
+		}
+		line = line[k+len(end):]
+	}
+}
+
+// isLineComment - line starts with a line comment marker
+func (l locLanguage) isLineComment(line string) bool {
+	for _, prefix := range l.LineComments {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockStart - line starts with a block comment opening marker
+func (l locLanguage) blockStart(line string) (string, string, bool) {
+	for _, block := range l.BlockComments {
+		if strings.HasPrefix(line, block[0]) {
+			return block[0], block[1], true
+		}
+	}
+	return "", "", false
+}
+
+// isComment - remaining part of a line is a comment only
+func (l locLanguage) isComment(line string) bool {
+	if l.isLineComment(line) {
+		return true
+	}
+	_, _, ok := l.blockStart(line)
+	return ok
+}
+
+// locCounter - counts lines of code in git trees, results are memoized by blob hash
+// so counting many trees of the same repository only reads changed blobs
+type locCounter struct {
+	mtx   *sync.Mutex
+	blobs map[plumbing.Hash]locBlob
+}
+
+// locBlob - memoized blob count
+type locBlob struct {
+	Binary bool
+	Counts clocResult
+}
+
+// newLocCounter - creates line counter with an empty blob memo
+func newLocCounter() *locCounter {
+	return &locCounter{mtx: &sync.Mutex{}, blobs: make(map[plumbing.Hash]locBlob)}
+}
+
+// CountTree - return cloc-like results for a tree: counts per language plus LocSumKey total
+// Files of unknown languages, binary and very large files are skipped, as cloc does
+func (c *locCounter) CountTree(tree *object.Tree) (map[string]clocResult, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	res := make(map[string]clocResult)
+	var sum clocResult
+	err := tree.Files().ForEach(func(f *object.File) error {
+		if !f.Mode.IsFile() {
+			return nil
+		}
+		lang, known := detectLocLanguage(f.Name)
+		if !known || f.Size > LocMaxFileSize {
+			return nil
+		}
+		blob, ok := c.blobs[f.Hash]
+		if !ok {
+			if binary, _ := f.IsBinary(); binary {
+				blob.Binary = true
+			} else {
+				contents, err := f.Contents()
+				if err != nil {
+					return err
+				}
+				blob.Counts = countLines(contents, lang)
+			}
+			c.blobs[f.Hash] = blob
+		}
+		if blob.Binary {
+			return nil
+		}
+		r := res[lang.Name]
+		r.add(blob.Counts)
+		res[lang.Name] = r
+		sum.add(blob.Counts)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res[LocSumKey] = sum
+	return res, nil
+}
+
+// CountCommit - return cloc-like results for a commit's tree
+func (c *locCounter) CountCommit(r *goGit.Repository, sha string) (map[string]clocResult, error) {
+	commit, err := r.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return c.CountTree(tree)
+}
+
+// add - add other counts
+func (r *clocResult) add(o clocResult) {
+	r.Code += o.Code
+	r.Blank += o.Blank
+	r.Comment += o.Comment
+	r.NumberOfFiles += o.NumberOfFiles
+}
+
+// locSummary - convert cloc-like results into total lines of code and programming languages summary
+// sorted by code lines descending, like the summary reported by gitops
+func locSummary(res map[string]clocResult) (loc int, pls []PLS) {
+	for lang, r := range res {
+		if lang == LocSumKey {
+			continue
+		}
+		pls = append(pls, PLS{Language: lang, Files: r.NumberOfFiles, Blank: r.Blank, Comment: r.Comment, Code: r.Code})
+	}
+	sort.Slice(pls, func(i, j int) bool {
+		if pls[i].Code != pls[j].Code {
+			return pls[i].Code > pls[j].Code
+		}
+		return pls[i].Language < pls[j].Language
+	})
+	loc = res[LocSumKey].Code
+	return
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestCountLines(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		file     string
+		contents string
+		expected clocResult
+	}{
+		{
+			name:     "empty file",
+			file:     "empty.go",
+			contents: "",
+			expected: clocResult{NumberOfFiles: 1},
+		},
+		{
+			name:     "go code, comments and blank lines",
+			file:     "main.go",
+			contents: "package main\n\n// comment\nfunc main() {}\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 2, Comment: 1, Blank: 1},
+		},
+		{
+			name:     "go block comment",
+			file:     "main.go",
+			contents: "/*\n  doc\n*/\npackage main\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 1, Comment: 3},
+		},
+		{
+			name:     "go code opening block comment and code after it closes",
+			file:     "main.go",
+			contents: "x := 1 /* start\n  still */ y := 2\nz := 3\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 3},
+		},
+		{
+			name:     "go block comment closed on the same line",
+			file:     "main.go",
+			contents: "x := 1 /* note */\ny := 2\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 2},
+		},
+		{
+			name:     "python one-line docstring",
+			file:     "f.py",
+			contents: "def f():\n    \"\"\"Docstring.\"\"\"\n    return 1\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 2, Comment: 1},
+		},
+		{
+			name:     "python one-line single quoted docstring",
+			file:     "f.py",
+			contents: "'''Docstring.'''\nprint(1)\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 1, Comment: 1},
+		},
+		{
+			name:     "python one-line triple quoted string in code",
+			file:     "f.py",
+			contents: "x = \"\"\"abc\"\"\"\ny = 1\n# comment\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 2, Comment: 1},
+		},
+		{
+			name:     "python multi-line docstring",
+			file:     "f.py",
+			contents: "\"\"\"\nModule doc.\n\"\"\"\nimport os\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 1, Comment: 3},
+		},
+		{
+			name:     "python code opening multi-line string",
+			file:     "f.py",
+			contents: "x = \"\"\"abc\ndef\n\"\"\"\ny = 1\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 2, Comment: 2},
+		},
+		{
+			name:     "python two docstrings on one line",
+			file:     "f.py",
+			contents: "\"\"\"a\"\"\" \"\"\"b\ndef f():\n  pass\n\"\"\"\nz = 1\n",
+			expected: clocResult{NumberOfFiles: 1, Code: 1, Comment: 4},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lang, ok := detectLocLanguage(tc.file)
+			if !ok {
+				t.Fatalf("language of %s not detected", tc.file)
+			}
+			got := countLines(tc.contents, lang)
+			if got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	var testCases = []struct {
+		file     string
+		expected string
+	}{
+		{file: "main.go", expected: "Go"},
+		{file: "cmd/git/git.go", expected: "Go"},
+		{file: "include/x.h", expected: "C/C++ Header"},
+		{file: "src/App.TSX", expected: "TypeScript"},
+		{file: "setup.py", expected: "Python"},
+		{file: "deploy/values.yml", expected: "YAML"},
+		{file: "Makefile", expected: "make"},
+		{file: "sub/dir/Makefile", expected: "make"},
+		{file: "Dockerfile", expected: "Dockerfile"},
+		{file: "Dockerfile.dev", expected: "Dockerfile"},
+		{file: "build/app.dockerfile", expected: "Dockerfile"},
+		{file: "CMakeLists.txt", expected: "CMake"},
+		{file: "notes.txt", expected: ""},
+		{file: "README", expected: ""},
+		{file: "image.png", expected: ""},
+		{file: ".gitignore", expected: ""},
+		{file: "", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			if got := DetectLanguage(tc.file); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestOpenBlock(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		file     string
+		line     string
+		expected string
+	}{
+		{name: "go no comment", file: "a.go", line: "x := 1", expected: ""},
+		{name: "go line comment only", file: "a.go", line: "x := 1 // note", expected: ""},
+		{name: "go open block", file: "a.go", line: "x := 1 /* start", expected: "*/"},
+		{name: "go closed block", file: "a.go", line: "x := 1 /* a */ y := 2", expected: ""},
+		{name: "go closed then open block", file: "a.go", line: "/* a */ x /* b", expected: "*/"},
+		{name: "python open docstring", file: "a.py", line: `x = """abc`, expected: `"""`},
+		{name: "python closed docstring", file: "a.py", line: `"""abc"""`, expected: ""},
+		{name: "python two docstrings, second open", file: "a.py", line: `"""a""" """b`, expected: `"""`},
+		{name: "python single quoted docstring", file: "a.py", line: `'''abc`, expected: `'''`},
+		{name: "python earliest marker wins", file: "a.py", line: `'''a """ b`, expected: `'''`},
+		{name: "html open comment", file: "a.html", line: "<p>x</p> <!-- start", expected: "-->"},
+		{name: "vue script block inside markup", file: "a.vue", line: "<!-- a --> x /* b", expected: "*/"},
+		{name: "haskell open block", file: "a.hs", line: "f x = x {- note", expected: "-}"},
+		{name: "lua open block", file: "a.lua", line: "x = 1 --[[ note", expected: "]]"},
+		{name: "ruby open block", file: "a.rb", line: "=begin", expected: "=end"},
+		{name: "no block comments", file: "a.sh", line: "echo /* x", expected: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lang, ok := detectLocLanguage(tc.file)
+			if !ok {
+				t.Fatalf("language of %s not detected", tc.file)
+			}
+			if got := lang.openBlock(tc.line); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLocCounterCountTree(t *testing.T) {
+	tr := newTestRepo(t)
+	goFile := "package main\n\n// comment\nfunc main() {}\n"
+	tree := tr.files(map[string]string{
+		"a.go":      goFile,
+		"b.go":      goFile,
+		"c.py":      "import os\n",
+		"README":    "not counted\n",
+		"data.json": "{\"a\":\x00}\n",
+		"large.sql": strings.Repeat("x\n", LocMaxFileSize/2+1),
+	})
+	commit := tr.commitTree("loc", time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), tree)
+	c := newLocCounter()
+	res, err := c.CountCommit(tr.r, commit.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]clocResult{
+		"Go":      {NumberOfFiles: 2, Code: 4, Comment: 2, Blank: 2},
+		"Python":  {NumberOfFiles: 1, Code: 1},
+		LocSumKey: {NumberOfFiles: 3, Code: 5, Comment: 2, Blank: 2},
+	}
+	if !reflect.DeepEqual(expected, res) {
+		t.Errorf("expected %+v, got %+v", expected, res)
+	}
+	// a.go and b.go share a blob, the large file is skipped before reading it
+	if len(c.blobs) != 3 {
+		t.Errorf("expected 3 memoized blobs, got %d", len(c.blobs))
+	}
+	if !c.blobs[tr.blob("{\"a\":\x00}\n")].Binary {
+		t.Errorf("expected binary blob to be memoized as binary")
+	}
+	// counting another tree with the same blob uses the memoized counts
+	goHash := tr.blob(goFile)
+	c.blobs[goHash] = locBlob{Counts: clocResult{NumberOfFiles: 1, Code: 100}}
+	res, err = c.CountTree(treeObject(t, tr, tr.files(map[string]string{"d.go": goFile})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := res["Go"]; got.Code != 100 {
+		t.Errorf("expected memoized count of 100 code lines, got %+v", got)
+	}
+}
+
+func treeObject(t *testing.T, tr *testRepo, hash plumbing.Hash) *object.Tree {
+	tree, err := tr.r.TreeObject(hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return tree
+}
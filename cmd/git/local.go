@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// GitFileURLPrefix - file:// URL prefix
+	GitFileURLPrefix = "file://"
+	// GitBundleSuffix - git bundle file suffix
+	GitBundleSuffix = ".bundle"
+)
+
+// isLocalSource - is git URL a file:// URL, a local repository directory or a bundle file
+// anything without a scheme that is not scp-like SSH URL (git@host:org/repo) is a local path, the same way git treats it
+func isLocalSource(source string) bool {
+	if strings.HasPrefix(source, GitFileURLPrefix) {
+		return true
+	}
+	if strings.Contains(source, "://") {
+		return false
+	}
+	colon := strings.Index(source, ":")
+	slash := strings.Index(source, "/")
+	return colon < 0 || (slash >= 0 && slash < colon)
+}
+
+// localSourcePath - filesystem path of a local source
+func localSourcePath(source string) string {
+	return strings.TrimPrefix(source, GitFileURLPrefix)
+}
+
+// cloneURL - URL, local repository or bundle the repository is cloned and fetched from
+// it differs from URL when a logical origin URL is set
+func (j *DSGit) cloneURL() string {
+	if j.cloneSource != "" {
+		return j.cloneSource
+	}
+	return j.URL
+}
+
+// resolveSource - check local sources and make their paths absolute, so fetching from them works from the clone directory
+// when a logical origin URL is set it replaces URL (repository URL, ID and commit links) and URL is only used to clone
+func (j *DSGit) resolveSource() (err error) {
+	j.cloneSource = ""
+	source := j.URL
+	if source != "" && isLocalSource(source) {
+		path := os.ExpandEnv(localSourcePath(source))
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return
+		}
+		if _, err = os.Stat(path); err != nil {
+			err = fmt.Errorf("cannot access local repository %s: %+v", path, err)
+			return
+		}
+		source = path
+		if strings.HasPrefix(j.URL, GitFileURLPrefix) {
+			source = GitFileURLPrefix + path
+		}
+		j.URL = source
+	}
+	if j.OriginURL != "" {
+		j.cloneSource = source
+		j.URL = RedactURL(strings.TrimSuffix(strings.TrimSpace(j.OriginURL), "/"))
+	}
+	return
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MailmapFile - repository mailmap file name
+	MailmapFile = ".mailmap"
+)
+
+// Mailmap - git mailmap, see gitmailmap(5)
+// Entries are keyed by lower case commit email, then by lower case commit name ("" matches any name)
+type Mailmap struct {
+	entries map[string]map[string]mailmapEntry
+}
+
+// mailmapEntry - proper name and email, empty when only the other one is replaced
+type mailmapEntry struct {
+	Name  string
+	Email string
+}
+
+// MailmapOriginal - identity remapped by mailmap, original values are kept for audit
+type MailmapOriginal struct {
+	Role          string `json:"role"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	OriginalName  string `json:"original_name"`
+	OriginalEmail string `json:"original_email"`
+}
+
+// NewMailmap - creates empty mailmap
+func NewMailmap() *Mailmap {
+	return &Mailmap{entries: make(map[string]map[string]mailmapEntry)}
+}
+
+// Parse - add entries from mailmap file contents, later entries override earlier ones
+// Supported forms:
+// Proper Name <commit@email>
+// <proper@email> <commit@email>
+// Proper Name <proper@email> <commit@email>
+// Proper Name <proper@email> Commit Name <commit@email>
+func (m *Mailmap) Parse(data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		var (
+			names  []string
+			emails []string
+		)
+		for {
+			open := strings.Index(line, "<")
+			if open < 0 {
+				break
+			}
+			end := strings.Index(line[open:], ">")
+			if end < 0 {
+				break
+			}
+			names = append(names, strings.TrimSpace(line[:open]))
+			emails = append(emails, strings.TrimSpace(line[open+1:open+end]))
+			line = line[open+end+1:]
+		}
+		switch len(emails) {
+		case 1:
+			m.add(names[0], emails[0], "", emails[0])
+		case 2:
+			m.add(names[0], emails[0], names[1], emails[1])
+		}
+	}
+}
+
+// add - add a single mailmap entry
+func (m *Mailmap) add(properName, properEmail, commitName, commitEmail string) {
+	if commitEmail == "" && commitName == "" {
+		return
+	}
+	email := strings.ToLower(commitEmail)
+	if _, ok := m.entries[email]; !ok {
+		m.entries[email] = make(map[string]mailmapEntry)
+	}
+	entry := mailmapEntry{Name: properName}
+	if properEmail != commitEmail {
+		entry.Email = properEmail
+	}
+	m.entries[email][strings.ToLower(commitName)] = entry
+}
+
+// Len - return number of mailmap entries
+func (m *Mailmap) Len() (n int) {
+	for _, names := range m.entries {
+		n += len(names)
+	}
+	return
+}
+
+// Map - return canonical name and email, an entry matching both name and email wins over email only entry
+func (m *Mailmap) Map(name, email string) (string, string) {
+	names, ok := m.entries[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+	entry, ok := names[strings.ToLower(name)]
+	if !ok {
+		entry, ok = names[""]
+		if !ok {
+			return name, email
+		}
+	}
+	if entry.Name != "" {
+		name = entry.Name
+	}
+	if entry.Email != "" {
+		email = entry.Email
+	}
+	return name, email
+}
+
+// loadMailmap - read repository's .mailmap at HEAD and then operator supplied mailmap file which takes precedence
+func (j *DSGit) loadMailmap(ctx *shared.Ctx, r *goGit.Repository) (err error) {
+	mailmap := NewMailmap()
+	ref, err := r.Head()
+	if err != nil {
+		return
+	}
+	commit, err := r.CommitObject(ref.Hash())
+	if err != nil {
+		return
+	}
+	f, e := commit.File(MailmapFile)
+	if e == nil {
+		contents, e := f.Contents()
+		if e != nil {
+			err = e
+			return
+		}
+		mailmap.Parse([]byte(contents))
+	} else if e != object.ErrFileNotFound {
+		err = e
+		return
+	}
+	if j.Mailmap != "" {
+		data, e := os.ReadFile(j.Mailmap)
+		if e != nil {
+			err = fmt.Errorf("cannot read mailmap %s: %+v", j.Mailmap, e)
+			return
+		}
+		mailmap.Parse(data)
+	}
+	if ctx.Debug > 0 {
+		j.log.WithFields(logrus.Fields{"operation": "loadMailmap"}).Debugf("loaded %d mailmap entries", mailmap.Len())
+	}
+	j.state.mailmap = mailmap
+	return
+}
+
+// canonicalGitAuthor - map "Name <email>" git author through mailmap, returns changed=false when not remapped
+func (j *DSGit) canonicalGitAuthor(author string) (canonical string, changed bool) {
+	canonical = author
+	if j.state == nil || j.state.mailmap == nil {
+		return
+	}
+	fields := strings.Split(author, "<")
+	name := strings.TrimSpace(fields[0])
+	email := ""
+	if len(fields) > 1 {
+		email = strings.TrimSpace(strings.Split(fields[1], ">")[0])
+	}
+	properName, properEmail := j.state.mailmap.Map(name, email)
+	if properName == name && properEmail == email {
+		return
+	}
+	canonical = fmt.Sprintf("%s <%s>", properName, properEmail)
+	changed = true
+	return
+}
@@ -0,0 +1,144 @@
+package main
+
+import "testing"
+
+func TestMailmap(t *testing.T) {
+	var testCases = []struct {
+		name          string
+		mailmap       string
+		commitName    string
+		commitEmail   string
+		expectedName  string
+		expectedEmail string
+	}{
+		{
+			name:          "proper name by commit email",
+			mailmap:       "Jane Doe <jane@example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "proper email by commit email",
+			mailmap:       "<jane@example.com> <jdoe@old.example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jdoe@old.example.com",
+			expectedName:  "jdoe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "proper name and email by commit email",
+			mailmap:       "Jane Doe <jane@example.com> <jdoe@old.example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jdoe@old.example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "proper name and email by commit name and email",
+			mailmap:       "Jane Doe <jane@example.com> jdoe <shared@example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "commit name not matching name and email entry",
+			mailmap:       "Jane Doe <jane@example.com> jdoe <shared@example.com>\n",
+			commitName:    "John Roe",
+			commitEmail:   "shared@example.com",
+			expectedName:  "John Roe",
+			expectedEmail: "shared@example.com",
+		},
+		{
+			name:          "name and email entry wins over email only entry",
+			mailmap:       "Jane Doe <jane@example.com> jdoe <shared@example.com>\nShared Account <shared@example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "email only entry for other names",
+			mailmap:       "Jane Doe <jane@example.com> jdoe <shared@example.com>\nShared Account <shared@example.com>\n",
+			commitName:    "John Roe",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Shared Account",
+			expectedEmail: "shared@example.com",
+		},
+		{
+			name:          "commit email and name are case-insensitive",
+			mailmap:       "Jane Doe <jane@example.com> JDoe <JDoe@Old.Example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jdoe@OLD.example.COM",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "later entry overrides earlier one",
+			mailmap:       "Jane <jane@example.com>\nJane Doe <jane@example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "comments and blank lines",
+			mailmap:       "# Jane Roe <jane@example.com>\n\nJane Doe <jane@example.com> # was jdoe\n",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "Jane Doe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "commented out entry",
+			mailmap:       "# Jane Doe <jane@example.com>\n",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "jdoe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "malformed lines are ignored",
+			mailmap:       "Jane Doe jane@example.com\nJane Doe <jane@example.com\n",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "jdoe",
+			expectedEmail: "jane@example.com",
+		},
+		{
+			name:          "unknown email",
+			mailmap:       "Jane Doe <jane@example.com>\n",
+			commitName:    "John Roe",
+			commitEmail:   "john@example.com",
+			expectedName:  "John Roe",
+			expectedEmail: "john@example.com",
+		},
+		{
+			name:          "empty mailmap",
+			commitName:    "jdoe",
+			commitEmail:   "jane@example.com",
+			expectedName:  "jdoe",
+			expectedEmail: "jane@example.com",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewMailmap()
+			m.Parse([]byte(tc.mailmap))
+			name, email := m.Map(tc.commitName, tc.commitEmail)
+			if name != tc.expectedName || email != tc.expectedEmail {
+				t.Errorf("expected %s <%s>, got %s <%s>", tc.expectedName, tc.expectedEmail, name, email)
+			}
+		})
+	}
+}
+
+func TestMailmapLen(t *testing.T) {
+	m := NewMailmap()
+	m.Parse([]byte("# comment\nJane Doe <jane@example.com>\nJane Doe <jane@example.com> jdoe <JANE@example.com>\n<jane@example.com> <jdoe@old.example.com>\nbroken line\n"))
+	if m.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", m.Len())
+	}
+}
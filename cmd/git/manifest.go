@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// GitDefaultManifestWorkers - default number of repositories synced in parallel in manifest mode
+	GitDefaultManifestWorkers = 4
+)
+
+// ManifestEntry - single repository to sync in manifest (batch) mode
+type ManifestEntry struct {
+	URL              string `yaml:"url" json:"url"`
+	OriginURL        string `yaml:"origin_url" json:"origin_url"`
+	RepositorySource string `yaml:"repository_source" json:"repository_source"`
+	SourceID         string `yaml:"source_id" json:"source_id"`
+	Project          string `yaml:"project" json:"project"`
+	DateFrom         string `yaml:"date_from" json:"date_from"`
+	DateTo           string `yaml:"date_to" json:"date_to"`
+	Username         string `yaml:"username" json:"username"`
+	PasswordFile     string `yaml:"password_file" json:"password_file"`
+}
+
+// Manifest - list of repositories to sync, can also be given as a top level list
+type Manifest struct {
+	Repositories []ManifestEntry `yaml:"repositories" json:"repositories"`
+}
+
+// ManifestResult - sync result of a single manifest entry
+type ManifestResult struct {
+	URL        string    `json:"url"`
+	Project    string    `json:"project,omitempty"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Seconds    float64   `json:"seconds"`
+}
+
+// ManifestSummary - summary written after all manifest entries are processed
+type ManifestSummary struct {
+	Manifest  string           `json:"manifest"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Cancelled int              `json:"cancelled,omitempty"`
+	Results   []ManifestResult `json:"results"`
+}
+
+// ReadManifest - read YAML or JSON manifest file, JSON is parsed as YAML
+func ReadManifest(path string) (entries []ManifestEntry, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var manifest Manifest
+	if e := yaml.Unmarshal(data, &manifest); e == nil && len(manifest.Repositories) > 0 {
+		entries = manifest.Repositories
+	} else if err = yaml.Unmarshal(data, &entries); err != nil {
+		err = fmt.Errorf("cannot parse manifest %s: %+v", path, err)
+		return
+	}
+	for i, entry := range entries {
+		entries[i].URL = strings.TrimSpace(entry.URL)
+		if entries[i].URL == "" {
+			err = fmt.Errorf("manifest %s entry #%d: url must be set", path, i+1)
+			return
+		}
+		if _, ok := parseManifestDate(entry.DateFrom); !ok && entry.DateFrom != "" {
+			err = fmt.Errorf("manifest %s entry #%d: cannot parse date_from: %s", path, i+1, entry.DateFrom)
+			return
+		}
+		if _, ok := parseManifestDate(entry.DateTo); !ok && entry.DateTo != "" {
+			err = fmt.Errorf("manifest %s entry #%d: cannot parse date_to: %s", path, i+1, entry.DateTo)
+			return
+		}
+	}
+	return
+}
+
+// parseManifestDate - parse manifest date given as RFC3339, YYYY-MM-DD or YYYY-MM
+func parseManifestDate(s string) (dt time.Time, ok bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return
+	}
+	for _, format := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006-01"} {
+		var err error
+		dt, err = time.Parse(format, s)
+		if err == nil {
+			dt = dt.UTC()
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// manifestSync - return DSGit configured like j, but for a given manifest entry and with a clean sync state
+func (j *DSGit) manifestSync(entry ManifestEntry) (r *DSGit, err error) {
+	c := *j
+	r = &c
+	r.Manifest = ""
+	r.URL = entry.URL
+	r.OriginURL = strings.TrimSpace(entry.OriginURL)
+	r.cloneSource = ""
+	if entry.RepositorySource != "" {
+		r.RepositorySource = entry.RepositorySource
+	}
+	r.SourceID = entry.SourceID
+	r.useEntryCredentials(entry)
+	r.RepoName = ""
+	r.Loc = 0
+	r.Pls = nil
+	r.GitPath = ""
+	r.LineScanner = nil
+	r.CurrLine = 0
+	r.ParseState = GitParseStateInit
+	r.Commit = nil
+	r.CommitFiles = nil
+	r.RecentLines = nil
+	r.OrphanedCommits = nil
+	r.OrphanedMap = make(map[string]struct{})
+	r.DefaultBranch = ""
+	r.Branches = nil
+	r.CurrentSHA = ""
+	r.CommitsHash = make(map[string]map[string]struct{})
+	r.headCommitHash = ""
+	r.headLinesOfCode = 0
+	r.state = nil
+	r.log = j.log.WithFields(logrus.Fields{"endpoint": RedactURL(entry.URL)})
+	if err = r.Validate(); err != nil {
+		return
+	}
+	if err = r.AddCacheProvider(); err != nil {
+		return
+	}
+	r.AddReportProvider()
+	return
+}
+
+// useEntryCredentials - entry credentials replace the shared ones and are not scoped to the credentials host
+func (j *DSGit) useEntryCredentials(entry ManifestEntry) {
+	if entry.Username == "" && entry.PasswordFile == "" {
+		return
+	}
+	j.Username = entry.Username
+	j.PasswordFile = entry.PasswordFile
+	j.CredentialsHost = ""
+	j.credentials = &gitCredentials{}
+}
+
+// SyncManifest - sync all repositories listed in the manifest using a bounded worker pool
+// once cancelCtx is cancelled, repositories being synced are flushed and the remaining ones are not started
+func (j *DSGit) SyncManifest(cancelCtx context.Context, ctx *shared.Ctx) (summary ManifestSummary, err error) {
+	entries, err := ReadManifest(j.Manifest)
+	if err != nil {
+		return
+	}
+	summary.Manifest = j.Manifest
+	summary.Total = len(entries)
+	summary.Results = make([]ManifestResult, len(entries))
+	workers := j.ManifestWorkers
+	if workers <= 0 {
+		workers = GitDefaultManifestWorkers
+	}
+	if workers > len(entries) {
+		workers = len(entries)
+	}
+	j.log.WithFields(logrus.Fields{"operation": "SyncManifest"}).Infof("syncing %d repositories from %s using %d workers", len(entries), j.Manifest, workers)
+	syncEntry := func(entry ManifestEntry) (e error) {
+		r, e := j.manifestSync(entry)
+		if e != nil {
+			return
+		}
+		entryCtx := *ctx
+		entryCtx.DateFrom, entryCtx.DateTo = nil, nil
+		if dt, ok := parseManifestDate(entry.DateFrom); ok {
+			entryCtx.DateFrom = &dt
+		}
+		if dt, ok := parseManifestDate(entry.DateTo); ok {
+			entryCtx.DateTo = &dt
+		}
+		if entry.Project != "" {
+			entryCtx.Project = entry.Project
+		}
+		e = r.SyncV2(cancelCtx, &entryCtx)
+		return
+	}
+	idx := make(chan int)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				entry := entries[i]
+				result := ManifestResult{URL: RedactURL(entry.URL), Project: entry.Project, StartedAt: time.Now()}
+				if syncCancelled(cancelCtx) {
+					result.Status = Cancelled
+					summary.Results[i] = result
+					continue
+				}
+				e := syncEntry(entry)
+				result.FinishedAt = time.Now()
+				result.Seconds = result.FinishedAt.Sub(result.StartedAt).Seconds()
+				if e == ErrSyncCancelled {
+					result.Status = Cancelled
+					j.log.WithFields(logrus.Fields{"operation": "SyncManifest"}).Warningf("%s sync cancelled after %.3fs", result.URL, result.Seconds)
+				} else if e != nil {
+					result.Status = Failed
+					result.Error = e.Error()
+					j.log.WithFields(logrus.Fields{"operation": "SyncManifest"}).Errorf("%s sync failed after %.3fs: %+v", result.URL, result.Seconds, e)
+				} else {
+					result.Status = Success
+					j.log.WithFields(logrus.Fields{"operation": "SyncManifest"}).Infof("%s synced in %.3fs", result.URL, result.Seconds)
+				}
+				summary.Results[i] = result
+			}
+		}()
+	}
+	for i := range entries {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+	for _, result := range summary.Results {
+		switch result.Status {
+		case Success:
+			summary.Succeeded++
+		case Cancelled:
+			summary.Cancelled++
+		default:
+			summary.Failed++
+		}
+	}
+	err = j.writeManifestSummary(summary)
+	if err == nil && summary.Cancelled > 0 {
+		err = ErrSyncCancelled
+	}
+	return
+}
+
+// writeManifestSummary - log manifest summary and optionally write it to ManifestSummary file
+func (j *DSGit) writeManifestSummary(summary ManifestSummary) (err error) {
+	data, err := jsoniter.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return
+	}
+	j.log.WithFields(logrus.Fields{"operation": "SyncManifest"}).Infof("manifest %s: %d/%d repositories synced, %d failed\n%s", summary.Manifest, summary.Succeeded, summary.Total, summary.Failed, string(data))
+	if j.ManifestSummary != "" {
+		err = os.WriteFile(j.ManifestSummary, data, 0644)
+	}
+	return
+}
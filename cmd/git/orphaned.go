@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// reachableCommits - return set of all commits reachable from any ref, like `git rev-list --all`
+// Annotated tags are peeled to the commits they point to, refs pointing to other objects are ignored
+func reachableCommits(r *goGit.Repository) (map[plumbing.Hash]struct{}, error) {
+	reachable := make(map[plumbing.Hash]struct{})
+	var queue []plumbing.Hash
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		hash := ref.Hash()
+		for {
+			tag, e := r.TagObject(hash)
+			if e != nil {
+				break
+			}
+			if tag.TargetType != plumbing.TagObject && tag.TargetType != plumbing.CommitObject {
+				return nil
+			}
+			hash = tag.Target
+		}
+		queue = append(queue, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for len(queue) > 0 {
+		hash := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if _, ok := reachable[hash]; ok {
+			continue
+		}
+		commit, e := r.CommitObject(hash)
+		if e != nil {
+			// ref pointing to a tree or blob, or a commit missing from a shallow clone
+			continue
+		}
+		reachable[hash] = struct{}{}
+		for _, parent := range commit.ParentHashes {
+			if _, ok := reachable[parent]; !ok {
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return reachable, nil
+}
+
+// unreachableCommits - call fn for each commit object present in the repository storage that
+// is not reachable from any ref, for example commits removed by a force push or squashed commits
+func unreachableCommits(r *goGit.Repository, fn func(sha string) error) error {
+	reachable, err := reachableCommits(r)
+	if err != nil {
+		return err
+	}
+	// Empty repository or no refs at all, nothing can be considered orphaned
+	if len(reachable) == 0 {
+		return nil
+	}
+	iter, err := r.Storer.IterEncodedObjects(plumbing.CommitObject)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+		if _, ok := reachable[obj.Hash()]; ok {
+			return nil
+		}
+		return fn(obj.Hash().String())
+	})
+	if err == storer.ErrStop || err == io.EOF {
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// testRepo - in-memory bare repository with helpers writing objects and refs directly
+type testRepo struct {
+	t *testing.T
+	r *goGit.Repository
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	r, err := goGit.Init(memory.NewStorage(), nil)
+	if err != nil {
+		t.Fatalf("init repository: %v", err)
+	}
+	return &testRepo{t: t, r: r}
+}
+
+func (tr *testRepo) store(obj interface {
+	Encode(plumbing.EncodedObject) error
+}) plumbing.Hash {
+	o := tr.r.Storer.NewEncodedObject()
+	if err := obj.Encode(o); err != nil {
+		tr.t.Fatalf("encode object: %v", err)
+	}
+	hash, err := tr.r.Storer.SetEncodedObject(o)
+	if err != nil {
+		tr.t.Fatalf("store object: %v", err)
+	}
+	return hash
+}
+
+func (tr *testRepo) tree() plumbing.Hash {
+	return tr.store(&object.Tree{})
+}
+
+func (tr *testRepo) blob(contents string) plumbing.Hash {
+	o := tr.r.Storer.NewEncodedObject()
+	o.SetType(plumbing.BlobObject)
+	w, err := o.Writer()
+	if err != nil {
+		tr.t.Fatalf("write blob: %v", err)
+	}
+	if _, err = w.Write([]byte(contents)); err != nil {
+		tr.t.Fatalf("write blob: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		tr.t.Fatalf("write blob: %v", err)
+	}
+	hash, err := tr.r.Storer.SetEncodedObject(o)
+	if err != nil {
+		tr.t.Fatalf("store blob: %v", err)
+	}
+	return hash
+}
+
+// files - store a tree of regular files with given contents, file names must not contain a slash
+func (tr *testRepo) files(files map[string]string) plumbing.Hash {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tree := &object.Tree{}
+	for _, name := range names {
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: tr.blob(files[name])})
+	}
+	return tr.store(tree)
+}
+
+func (tr *testRepo) commit(message string, parents ...plumbing.Hash) plumbing.Hash {
+	return tr.commitAt(message, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), parents...)
+}
+
+func (tr *testRepo) commitAt(message string, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	return tr.commitTree(message, when, tr.tree(), parents...)
+}
+
+func (tr *testRepo) commitTree(message string, when time.Time, tree plumbing.Hash, parents ...plumbing.Hash) plumbing.Hash {
+	sig := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when}
+	return tr.store(&object.Commit{Author: sig, Committer: sig, Message: message, TreeHash: tree, ParentHashes: parents})
+}
+
+func (tr *testRepo) tag(name string, target plumbing.Hash, targetType plumbing.ObjectType) plumbing.Hash {
+	sig := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)}
+	return tr.store(&object.Tag{Name: name, Tagger: sig, Message: name, TargetType: targetType, Target: target})
+}
+
+func (tr *testRepo) setRef(name string, hash plumbing.Hash) {
+	if err := tr.r.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), hash)); err != nil {
+		tr.t.Fatalf("set reference %s: %v", name, err)
+	}
+}
+
+func (tr *testRepo) removeRef(name string) {
+	if err := tr.r.Storer.RemoveReference(plumbing.ReferenceName(name)); err != nil {
+		tr.t.Fatalf("remove reference %s: %v", name, err)
+	}
+}
+
+func TestUnreachableCommits(t *testing.T) {
+	var testCases = []struct {
+		name string
+		// setup builds the repository and returns commits expected to be reported as unreachable
+		setup func(tr *testRepo) []plumbing.Hash
+	}{
+		{
+			name: "no refs",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				tr.commit("a")
+				return nil
+			},
+		},
+		{
+			name: "all commits reachable",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				b := tr.commit("b", a)
+				tr.setRef("refs/heads/master", b)
+				return nil
+			},
+		},
+		{
+			name: "force-moved branch",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				b := tr.commit("b", a)
+				c := tr.commit("c", b)
+				tr.setRef("refs/heads/master", c)
+				d := tr.commit("d", a)
+				tr.setRef("refs/heads/master", d)
+				return []plumbing.Hash{b, c}
+			},
+		},
+		{
+			name: "deleted branch",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				f := tr.commit("feature", a)
+				tr.setRef("refs/heads/feature", f)
+				tr.removeRef("refs/heads/feature")
+				return []plumbing.Hash{f}
+			},
+		},
+		{
+			name: "merged branch deleted",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				f := tr.commit("feature", a)
+				m := tr.commit("merge", a, f)
+				tr.setRef("refs/heads/master", m)
+				tr.setRef("refs/heads/feature", f)
+				tr.removeRef("refs/heads/feature")
+				return nil
+			},
+		},
+		{
+			name: "lightweight tag",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				r := tr.commit("release", a)
+				tr.setRef("refs/tags/v1.0.0", r)
+				return nil
+			},
+		},
+		{
+			name: "annotated tag",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				r := tr.commit("release", a)
+				tr.setRef("refs/tags/v1.0.0", tr.tag("v1.0.0", r, plumbing.CommitObject))
+				return nil
+			},
+		},
+		{
+			name: "tag of tag",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				r := tr.commit("release", a)
+				inner := tr.tag("v1.0.0", r, plumbing.CommitObject)
+				tr.setRef("refs/tags/v1", tr.tag("v1", inner, plumbing.TagObject))
+				return nil
+			},
+		},
+		{
+			name: "annotated tag of deleted branch tip is removed",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				r := tr.commit("release", a)
+				tr.setRef("refs/tags/v1.0.0", tr.tag("v1.0.0", r, plumbing.CommitObject))
+				tr.removeRef("refs/tags/v1.0.0")
+				return []plumbing.Hash{r}
+			},
+		},
+		{
+			name: "ref pointing at a tree",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				tr.setRef("refs/tags/tree", tr.tree())
+				o := tr.commit("orphan", a)
+				return []plumbing.Hash{o}
+			},
+		},
+		{
+			name: "annotated tag of a tree",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commit("a")
+				tr.setRef("refs/heads/master", a)
+				tr.setRef("refs/tags/tree", tr.tag("tree", tr.tree(), plumbing.TreeObject))
+				o := tr.commit("orphan", a)
+				return []plumbing.Hash{o}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestRepo(t)
+			expected := []string{}
+			for _, hash := range tc.setup(tr) {
+				expected = append(expected, hash.String())
+			}
+			got := []string{}
+			err := unreachableCommits(tr.r, func(sha string) error {
+				got = append(got, sha)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sort.Strings(expected)
+			sort.Strings(got)
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
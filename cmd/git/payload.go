@@ -0,0 +1,80 @@
+package main
+
+import (
+	"github.com/LF-Engineering/lfx-event-schema/service"
+	"github.com/LF-Engineering/lfx-event-schema/service/insights/git"
+)
+
+// Commit - lfx-event-schema commit extended with git connector specific fields
+// Embedded git.Commit fields are flattened, so this is a superset of the schema payload
+type Commit struct {
+	git.Commit
+	// Contributors - shadows git.Commit.Contributors to flag bot and automation accounts
+	Contributors []Contributor `json:"contributors"`
+	// Files - shadows git.Commit.Files to add renamed and copied files counts
+	Files []CommitFilesByType `json:"files"`
+	// RenamedFiles - renamed (moved) files with their old path, new path and similarity
+	RenamedFiles []CommitRenamedFile `json:"renamed_files,omitempty"`
+	// CopiedFiles - copied files with the path they were copied from, new path and similarity
+	CopiedFiles []CommitRenamedFile `json:"copied_files,omitempty"`
+	// Branches - all branches the commit is reachable from, only set when all branches are traversed
+	Branches []string `json:"branches,omitempty"`
+	// Languages - commit's files aggregated by detected programming language
+	Languages []CommitLanguage `json:"languages,omitempty"`
+	// References - issues, JIRA keys, Gerrit changes, code reviews and bug tracker links referenced by commit message
+	References []CommitReference `json:"references,omitempty"`
+	// ConventionalCommit - type, scope, breaking flag and description parsed from Conventional Commits title
+	ConventionalCommit *ConventionalCommit `json:"conventional_commit,omitempty"`
+	// CommitType - revert or cherry_pick (backport), empty for other commits
+	CommitType string `json:"commit_type,omitempty"`
+	// RevertedSHA - SHA (possibly abbreviated) of the commit reverted by this one
+	RevertedSHA string `json:"reverted_sha,omitempty"`
+	// CherryPickedFrom - SHA (possibly abbreviated) of the original commit this one was cherry-picked from
+	CherryPickedFrom string `json:"cherry_picked_from,omitempty"`
+	// DCO - Signed-off-by trailer matching commit's author check result
+	DCO CommitDCO `json:"dco"`
+	// Signature - commit signature type (gpg, ssh, x509), signing key ID and verification status
+	Signature CommitSignature `json:"signature"`
+	// MailmapOriginals - contributors remapped by .mailmap with their original name and email
+	MailmapOriginals []MailmapOriginal `json:"mailmap_originals,omitempty"`
+	// LinesOfCode - lines of code of the commit's tree, only set for commits whose tree was counted, like HEAD
+	LinesOfCode int `json:"lines_of_code,omitempty"`
+	// StatsIncomplete - file list or line stats are missing or partial, commit was synced from a blobless or shallow clone
+	StatsIncomplete bool `json:"stats_incomplete,omitempty"`
+}
+
+// CommitFilesByType - lfx-event-schema files summary extended with renamed and copied files counts
+type CommitFilesByType struct {
+	git.CommitFilesByType
+	FilesRenamed int `json:"files_renamed"`
+	FilesCopied  int `json:"files_copied"`
+}
+
+// CommitRenamedFile - renamed or copied file, similarity is the percent of unchanged contents
+type CommitRenamedFile struct {
+	OldPath    string `json:"old_path"`
+	NewPath    string `json:"new_path"`
+	Similarity int    `json:"similarity"`
+}
+
+// CommitLanguage - lines added and removed by a commit in files of a given language
+type CommitLanguage struct {
+	Language     string `json:"language"`
+	Files        int    `json:"files"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+}
+
+// CommitCreatedEvent - commit.created event carrying extended commit payload
+type CommitCreatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload Commit `json:"payload,omitempty"`
+}
+
+// CommitUpdatedEvent - commit.updated event carrying extended commit payload
+type CommitUpdatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload Commit `json:"payload,omitempty"`
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	// LocalPublisherFileExt - extension of files written by LocalPublisher
+	LocalPublisherFileExt = ".ndjson"
+	// LocalPublisherDefaultMaxEvents - default maximum number of events stored in a single NDJSON file
+	LocalPublisherDefaultMaxEvents = 10000
+)
+
+// LocalPublisher - Publisher implementation writing events to rotating NDJSON files in a local directory
+// Files are named <dir>/<endpoint>/<eventType>-<subEventType>-<action>-<seq>.ndjson, one event per line
+type LocalPublisher struct {
+	Path      string // root directory where NDJSON files are stored
+	MaxEvents int    // rotate to the next file once the current one holds that many events
+	mtx       *sync.Mutex
+	files     map[string]*localPublisherFile
+}
+
+// localPublisherFile - state of the currently written file for a given endpoint/type/action
+type localPublisherFile struct {
+	seq    int
+	events int
+}
+
+// NewLocalPublisher - creates publisher writing into a given directory
+func NewLocalPublisher(path string, maxEvents int) (*LocalPublisher, error) {
+	if path == "" {
+		return nil, fmt.Errorf("local publisher path must be set")
+	}
+	if maxEvents <= 0 {
+		maxEvents = LocalPublisherDefaultMaxEvents
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalPublisher{
+		Path:      path,
+		MaxEvents: maxEvents,
+		mtx:       &sync.Mutex{},
+		files:     make(map[string]*localPublisherFile),
+	}, nil
+}
+
+// PushEvents - appends events to the current NDJSON file for a given action and returns that file's path
+// A single call never spans two files, so the returned path always contains every event from data
+func (p *LocalPublisher) PushEvents(action, source, eventType, subEventType, env string, data []interface{}, endpoint string) (string, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	dir := filepath.Join(p.Path, localPublisherSanitize(endpoint))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	prefix := strings.Join([]string{localPublisherSanitize(eventType), localPublisherSanitize(subEventType), localPublisherSanitize(action)}, "-")
+	key := filepath.Join(dir, prefix)
+	state, ok := p.files[key]
+	if !ok {
+		// Never overwrite files left by a previous run into the same directory
+		state = &localPublisherFile{seq: p.nextSeq(dir, prefix)}
+		p.files[key] = state
+	}
+	if state.events > 0 && state.events+len(data) > p.MaxEvents {
+		state.seq++
+		state.events = 0
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%05d%s", prefix, state.seq, LocalPublisherFileExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	w := bufio.NewWriter(f)
+	for _, item := range data {
+		line, err := jsoniter.Marshal(item)
+		if err != nil {
+			_ = f.Close()
+			return "", err
+		}
+		_, _ = w.Write(line)
+		_ = w.WriteByte('\n')
+	}
+	if err = w.Flush(); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	state.events += len(data)
+	return path, nil
+}
+
+// nextSeq - returns first sequence number not used by any file in dir
+func (p *LocalPublisher) nextSeq(dir, prefix string) int {
+	seq := 0
+	for {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%05d%s", prefix, seq, LocalPublisherFileExt))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return seq
+		}
+		seq++
+	}
+}
+
+// localPublisherSanitize - make a string safe to use as a single path element
+func localPublisherSanitize(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "default"
+	}
+	return strings.NewReplacer("/", "-", "\\", "-", ":", "-", " ", "_").Replace(s)
+}
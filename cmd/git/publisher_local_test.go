@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// readNDJSON - read events stored in an NDJSON file, one per line
+func readNDJSON(t *testing.T, path string) []map[string]interface{} {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	var events []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		if err = jsoniter.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("%s: invalid line %q: %v", path, scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// testEvents - n events with consecutive ids starting at from
+func testEvents(from, n int) []interface{} {
+	events := make([]interface{}, 0, n)
+	for i := from; i < from+n; i++ {
+		events = append(events, map[string]interface{}{"id": float64(i)})
+	}
+	return events
+}
+
+func TestLocalPublisherNDJSON(t *testing.T) {
+	dir := t.TempDir()
+	p, err := NewLocalPublisher(dir, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, err := p.PushEvents("created", "insights", "git", "commit", "test", testEvents(0, 3), "https://github.com/org/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedPath := filepath.Join(dir, "https---github.com-org-repo", "git-commit-created-00000"+LocalPublisherFileExt)
+	if path != expectedPath {
+		t.Errorf("expected path %s, got %s", expectedPath, path)
+	}
+	events := readNDJSON(t, path)
+	expected := []map[string]interface{}{{"id": float64(0)}, {"id": float64(1)}, {"id": float64(2)}}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+	// other action goes to its own file
+	path, err = p.PushEvents("updated", "insights", "git", "commit", "test", testEvents(3, 1), "https://github.com/org/repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Base(path) != "git-commit-updated-00000"+LocalPublisherFileExt {
+		t.Errorf("unexpected updated events file %s", path)
+	}
+	if _, err = NewLocalPublisher("", 10); err == nil {
+		t.Errorf("expected error for empty path")
+	}
+}
+
+func TestLocalPublisherRotation(t *testing.T) {
+	var testCases = []struct {
+		name      string
+		maxEvents int
+		pushes    []int
+		files     []string // file each push is written to
+		counts    map[string]int
+	}{
+		{
+			name:      "fits in one file",
+			maxEvents: 5,
+			pushes:    []int{2, 3},
+			files:     []string{"00000", "00000"},
+			counts:    map[string]int{"00000": 5},
+		},
+		{
+			name:      "rotates before exceeding max events",
+			maxEvents: 3,
+			pushes:    []int{2, 2, 1, 1},
+			files:     []string{"00000", "00001", "00001", "00002"},
+			counts:    map[string]int{"00000": 2, "00001": 3, "00002": 1},
+		},
+		{
+			name:      "push larger than max events is never split",
+			maxEvents: 2,
+			pushes:    []int{5, 1},
+			files:     []string{"00000", "00001"},
+			counts:    map[string]int{"00000": 5, "00001": 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p, err := NewLocalPublisher(dir, tc.maxEvents)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			id := 0
+			for i, n := range tc.pushes {
+				path, err := p.PushEvents("created", "insights", "git", "commit", "test", testEvents(id, n), "repo")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				id += n
+				expected := "git-commit-created-" + tc.files[i] + LocalPublisherFileExt
+				if filepath.Base(path) != expected {
+					t.Errorf("push #%d: expected file %s, got %s", i+1, expected, filepath.Base(path))
+				}
+			}
+			for seq, count := range tc.counts {
+				path := filepath.Join(dir, "repo", "git-commit-created-"+seq+LocalPublisherFileExt)
+				if events := readNDJSON(t, path); len(events) != count {
+					t.Errorf("%s: expected %d events, got %d", seq, count, len(events))
+				}
+			}
+		})
+	}
+}
+
+func TestLocalPublisherKeepsPreviousRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	p, err := NewLocalPublisher(dir, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := p.PushEvents("created", "insights", "git", "commit", "test", testEvents(0, 2), "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// next run into the same directory continues with the next file
+	p, err = NewLocalPublisher(dir, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := p.PushEvents("created", "insights", "git", "commit", "test", testEvents(2, 1), "repo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Base(second) != "git-commit-created-00001"+LocalPublisherFileExt {
+		t.Errorf("expected second run to write the next file, got %s", second)
+	}
+	if events := readNDJSON(t, first); len(events) != 2 {
+		t.Errorf("expected previous run file to keep 2 events, got %d", len(events))
+	}
+}
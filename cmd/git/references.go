@@ -0,0 +1,158 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// ReferenceTypeIssue - GitHub/GitLab style issue or pull request reference: #123, org/repo#45 or issue URL
+	ReferenceTypeIssue = "issue"
+	// ReferenceTypeJira - JIRA-style issue key: PROJ-123
+	ReferenceTypeJira = "jira"
+	// ReferenceTypeChangeID - Gerrit Change-Id trailer
+	ReferenceTypeChangeID = "change_id"
+	// ReferenceTypeReview - code review URL from Reviewed-on trailer
+	ReferenceTypeReview = "review"
+	// ReferenceTypeBug - bug tracker link or bug trailer (Bugzilla, Launchpad, Bug: 123)
+	ReferenceTypeBug = "bug"
+)
+
+// CommitReference - issue, code review or bug reference found in a commit message
+// Repository is only set when the reference points to another repository (org/repo#45 or a URL)
+type CommitReference struct {
+	Type       string `json:"type"`
+	Action     string `json:"action,omitempty"`
+	ID         string `json:"id"`
+	Repository string `json:"repository,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+var (
+	// GitReferenceActions - reference keywords (lowercase) -> normalized action
+	GitReferenceActions = map[string]string{
+		"close":       "closes",
+		"closes":      "closes",
+		"closed":      "closes",
+		"fix":         "fixes",
+		"fixes":       "fixes",
+		"fixed":       "fixes",
+		"resolve":     "resolves",
+		"resolves":    "resolves",
+		"resolved":    "resolves",
+		"ref":         "references",
+		"refs":        "references",
+		"references":  "references",
+		"see":         "references",
+		"related":     "references",
+		"related-to":  "references",
+		"closes-bug":  "closes",
+		"partial-bug": "partial",
+		"related-bug": "references",
+	}
+	// GitJiraKeyDenyList - uppercase prefixes looking like JIRA keys but denoting standards and encodings
+	GitJiraKeyDenyList = map[string]struct{}{
+		"UTF": {}, "UCS": {}, "SHA": {}, "MD": {}, "ISO": {}, "CVE": {}, "CWE": {}, "GHSA": {}, "RFC": {}, "PEP": {},
+		"GPL": {}, "LGPL": {}, "AGPL": {}, "AES": {}, "RSA": {}, "TLS": {}, "SSL": {}, "HTTP": {}, "ECMA": {}, "IEEE": {},
+		"X": {}, "ARM": {}, "DDR": {}, "PCI": {}, "USB": {}, "WIN": {}, "RC": {},
+	}
+	// optional keyword before an issue reference or JIRA key: "Fixes #1", "closes org/repo#45", "#7", "Refs: PROJ-12"
+	referenceKeyword = `(?i:(close[sd]?|fix(?:e[sd])?|resolve[sd]?|refs?|references|see|related(?:[\s\-]to)?)[:\s]+)?`
+	referenceIssueRE = regexp.MustCompile(`(?:^|[\s(\[,;])` + referenceKeyword + `([\w.\-]+/[\w.\-]+)?#(\d+)\b`)
+	// GitHub/GitLab issue, pull request and merge request URLs
+	referenceIssueURLRE = regexp.MustCompile(`https?://(?:www\.)?(?:github\.com|gitlab\.com)/([\w.\-]+/[\w.\-/]+?)(?:/-)?/(?:issues|pull|merge_requests)/(\d+)`)
+	// JIRA-style keys and JIRA browse URLs
+	referenceJiraRE    = regexp.MustCompile(`(?:^|[^\w\-/])` + referenceKeyword + `([A-Z][A-Z0-9]{1,9})-(\d+)\b`)
+	referenceJiraURLRE = regexp.MustCompile(`https?://\S+?/browse/([A-Z][A-Z0-9]{1,9}-\d+)`)
+	// Bugzilla and Launchpad links
+	referenceBugURLRE = regexp.MustCompile(`https?://\S+?(?:show_bug\.cgi\?id=|/\+bug/)(\d+)`)
+	// trailers: Change-Id, Reviewed-on and bug trailers (Bug, Closes-Bug, Partial-Bug, Related-Bug, Bugzilla, LP)
+	referenceChangeIDRE = regexp.MustCompile(`(?i)^change-id:\s*(I[0-9a-f]{8,40})\s*$`)
+	referenceReviewRE   = regexp.MustCompile(`(?i)^reviewed-on:\s*(\S+)\s*$`)
+	referenceBugRE      = regexp.MustCompile(`(?i)^(bugs?|closes-bug|partial-bug|related-bug|bugzilla|lp|bug-url):\s*(.+)$`)
+	referenceNumberRE   = regexp.MustCompile(`(\d+)/?$`)
+	referenceBugIDsRE   = regexp.MustCompile(`#?(\d+)\b`)
+	referenceURLRE      = regexp.MustCompile(`^https?://\S+$`)
+	referenceURLsRE     = regexp.MustCompile(`https?://\S+`)
+)
+
+// ExtractReferences - extract issue, JIRA, Gerrit change and bug tracker references from a commit message
+// references are returned in order of appearance, deduplicated by type, repository and ID
+func ExtractReferences(message string) (refs []CommitReference) {
+	seen := make(map[[3]string]int)
+	add := func(ref CommitReference) {
+		key := [3]string{ref.Type, strings.ToLower(ref.Repository), ref.ID}
+		if ref.ID == "" {
+			key[2] = ref.URL
+		}
+		if i, ok := seen[key]; ok {
+			// keep the most specific action and URL of duplicated reference
+			if refs[i].Action == "" {
+				refs[i].Action = ref.Action
+			}
+			if refs[i].URL == "" {
+				refs[i].URL = ref.URL
+			}
+			return
+		}
+		seen[key] = len(refs)
+		refs = append(refs, ref)
+	}
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := referenceChangeIDRE.FindStringSubmatch(line); m != nil {
+			add(CommitReference{Type: ReferenceTypeChangeID, ID: m[1]})
+			continue
+		}
+		if m := referenceReviewRE.FindStringSubmatch(line); m != nil {
+			ref := CommitReference{Type: ReferenceTypeReview, URL: m[1]}
+			if n := referenceNumberRE.FindStringSubmatch(m[1]); n != nil {
+				ref.ID = n[1]
+			}
+			add(ref)
+			continue
+		}
+		if m := referenceBugRE.FindStringSubmatch(line); m != nil {
+			action := GitReferenceActions[strings.ToLower(m[1])]
+			value := strings.TrimSpace(m[2])
+			if referenceURLRE.MatchString(value) {
+				ref := CommitReference{Type: ReferenceTypeBug, Action: action, URL: value}
+				if n := referenceBugIDsRE.FindAllStringSubmatch(value, -1); n != nil {
+					ref.ID = n[len(n)-1][1]
+				}
+				add(ref)
+				continue
+			}
+			for _, n := range referenceBugIDsRE.FindAllStringSubmatch(value, -1) {
+				add(CommitReference{Type: ReferenceTypeBug, Action: action, ID: n[1]})
+			}
+			continue
+		}
+		for _, m := range referenceIssueURLRE.FindAllStringSubmatch(line, -1) {
+			add(CommitReference{Type: ReferenceTypeIssue, ID: m[2], Repository: m[1], URL: m[0]})
+		}
+		for _, m := range referenceBugURLRE.FindAllStringSubmatch(line, -1) {
+			add(CommitReference{Type: ReferenceTypeBug, ID: m[1], URL: m[0]})
+		}
+		for _, m := range referenceJiraURLRE.FindAllStringSubmatch(line, -1) {
+			add(CommitReference{Type: ReferenceTypeJira, ID: m[1], URL: m[0]})
+		}
+		// URLs are already handled, do not match their fragments as issues or keys
+		text := referenceURLsRE.ReplaceAllString(line, " ")
+		for _, m := range referenceIssueRE.FindAllStringSubmatch(text, -1) {
+			action := GitReferenceActions[strings.Join(strings.Fields(strings.ToLower(m[1])), "-")]
+			add(CommitReference{Type: ReferenceTypeIssue, Action: action, ID: m[3], Repository: m[2]})
+		}
+		for _, m := range referenceJiraRE.FindAllStringSubmatch(text, -1) {
+			if _, deny := GitJiraKeyDenyList[m[2]]; deny {
+				continue
+			}
+			action := GitReferenceActions[strings.Join(strings.Fields(strings.ToLower(m[1])), "-")]
+			add(CommitReference{Type: ReferenceTypeJira, Action: action, ID: m[2] + "-" + m[3]})
+		}
+	}
+	return
+}
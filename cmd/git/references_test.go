@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractReferences(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		message  string
+		expected []CommitReference
+	}{
+		{
+			name:     "issue",
+			message:  "Handle empty input #123",
+			expected: []CommitReference{{Type: ReferenceTypeIssue, ID: "123"}},
+		},
+		{
+			name:     "issue with keyword",
+			message:  "Handle empty input\n\nFixes #123",
+			expected: []CommitReference{{Type: ReferenceTypeIssue, Action: "fixes", ID: "123"}},
+		},
+		{
+			name:     "issue in parentheses",
+			message:  "Handle empty input (#123)",
+			expected: []CommitReference{{Type: ReferenceTypeIssue, ID: "123"}},
+		},
+		{
+			name:     "issue of other repository",
+			message:  "Closes org/repo#1",
+			expected: []CommitReference{{Type: ReferenceTypeIssue, Action: "closes", ID: "1", Repository: "org/repo"}},
+		},
+		{
+			name:    "several issues and keywords",
+			message: "Resolved #1, related to #2; see other.org/repo.js#3",
+			expected: []CommitReference{
+				{Type: ReferenceTypeIssue, Action: "resolves", ID: "1"},
+				{Type: ReferenceTypeIssue, Action: "references", ID: "2"},
+				{Type: ReferenceTypeIssue, Action: "references", ID: "3", Repository: "other.org/repo.js"},
+			},
+		},
+		{
+			name:     "duplicated issue keeps action",
+			message:  "Handle empty input #7\n\nFixes #7",
+			expected: []CommitReference{{Type: ReferenceTypeIssue, Action: "fixes", ID: "7"}},
+		},
+		{
+			name:    "issue URLs",
+			message: "See https://github.com/org/repo/issues/45 and https://gitlab.com/group/sub/repo/-/merge_requests/6",
+			expected: []CommitReference{
+				{Type: ReferenceTypeIssue, ID: "45", Repository: "org/repo", URL: "https://github.com/org/repo/issues/45"},
+				{Type: ReferenceTypeIssue, ID: "6", Repository: "group/sub/repo", URL: "https://gitlab.com/group/sub/repo/-/merge_requests/6"},
+			},
+		},
+		{
+			name:    "JIRA keys",
+			message: "PROJ-123: fix login\n\nRefs: AB2-7",
+			expected: []CommitReference{
+				{Type: ReferenceTypeJira, ID: "PROJ-123"},
+				{Type: ReferenceTypeJira, Action: "references", ID: "AB2-7"},
+			},
+		},
+		{
+			name:     "JIRA URL",
+			message:  "Details in https://issues.example.com/browse/PROJ-9",
+			expected: []CommitReference{{Type: ReferenceTypeJira, ID: "PROJ-9", URL: "https://issues.example.com/browse/PROJ-9"}},
+		},
+		{
+			name:    "Gerrit trailers",
+			message: "Fix race\n\nChange-Id: I0123456789abcdef0123456789abcdef01234567\nReviewed-on: https://review.example.com/c/project/+/12345",
+			expected: []CommitReference{
+				{Type: ReferenceTypeChangeID, ID: "I0123456789abcdef0123456789abcdef01234567"},
+				{Type: ReferenceTypeReview, ID: "12345", URL: "https://review.example.com/c/project/+/12345"},
+			},
+		},
+		{
+			name:    "bug trailers and links",
+			message: "Fix crash\n\nCloses-Bug: #1001\nBug: 7, 8\nBugzilla: https://bugzilla.example.com/show_bug.cgi?id=55\nSee https://bugs.launchpad.net/nova/+bug/77",
+			expected: []CommitReference{
+				{Type: ReferenceTypeBug, Action: "closes", ID: "1001"},
+				{Type: ReferenceTypeBug, ID: "7"},
+				{Type: ReferenceTypeBug, ID: "8"},
+				{Type: ReferenceTypeBug, ID: "55", URL: "https://bugzilla.example.com/show_bug.cgi?id=55"},
+				{Type: ReferenceTypeBug, ID: "77", URL: "https://bugs.launchpad.net/nova/+bug/77"},
+			},
+		},
+		{
+			name:     "no references",
+			message:  "Update README",
+			expected: nil,
+		},
+		{
+			name:     "anchor in URL is not an issue",
+			message:  "Docs at https://example.com/guide#123",
+			expected: nil,
+		},
+		{
+			name:     "hash inside a word is not an issue",
+			message:  "Use C#7 features and abc#12",
+			expected: nil,
+		},
+		{
+			name:     "standards and encodings are not JIRA keys",
+			message:  "Use UTF-8, SHA-256 and TLS-1 per RFC-2119, fix CVE-2021-44228",
+			expected: nil,
+		},
+		{
+			name:     "lower case and hyphenated words are not JIRA keys",
+			message:  "Bump proj-123 and my-PROJ-5, x86-64 build",
+			expected: nil,
+		},
+		{
+			name:     "Change-Id in message body is not a trailer",
+			message:  "Drop the Change-Id: I0123456789abcdef hook",
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ExtractReferences(tc.message)
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	// GitFileActionAdded - file created
+	GitFileActionAdded = ""
+	// GitFileActionModified - file modified
+	GitFileActionModified = "M"
+	// GitFileActionDeleted - file deleted
+	GitFileActionDeleted = "D"
+	// GitFileActionRenamed - file renamed (moved), possibly with modifications
+	GitFileActionRenamed = "R"
+	// GitFileActionCopied - file copied from another file modified in the same commit
+	GitFileActionCopied = "C"
+	// GitSimilarityThreshold - minimum similarity (percent) for rename/copy detection, same as git's default -M50% -C50%
+	GitSimilarityThreshold = 50
+	// GitRenameLimit - inexact renames and copies are not detected in commits adding, deleting or modifying more files,
+	// same as git's default diff.renameLimit
+	GitRenameLimit = 1000
+)
+
+// fileChange - single file change of a commit compared to its first parent
+type fileChange struct {
+	Action     string
+	Path       string // new path, or old path for deleted files
+	OldPath    string // only set for renamed and copied files
+	Similarity int    // percent, only set for renamed and copied files
+	Added      int
+	Removed    int
+}
+
+// getFilesChanges - return commit's file changes compared to its first parent
+// Renames are detected by go-git, copies are detected the way `git log -C` does it:
+// an added file is a copy when it is similar enough to a file modified by the same commit
+// In a blobless clone file contents are missing: renames are not detected and line stats are zero,
+// complete is false then
+// Rename detection stops when cancelCtx is cancelled
+func getFilesChanges(cancelCtx context.Context, com object.Commit) (files []fileChange, complete bool, err error) {
+	to, err := com.Tree()
+	if err != nil {
+		return
+	}
+	var from *object.Tree
+	if com.NumParents() != 0 {
+		firstParent, e := com.Parents().Next()
+		if e != nil {
+			err = e
+			return
+		}
+		from, err = firstParent.Tree()
+		if err != nil {
+			return
+		}
+	}
+	complete = true
+	opts := *object.DefaultDiffTreeOptions
+	opts.RenameScore = GitSimilarityThreshold
+	opts.RenameLimit = GitRenameLimit
+	changes, err := object.DiffTreeWithOptions(cancelCtx, from, to, &opts)
+	if isMissingObject(err) {
+		// rename detection reads file contents, compare trees only
+		complete = false
+		changes, err = object.DiffTree(from, to)
+	}
+	if err != nil {
+		return
+	}
+	var (
+		added    []*object.Change
+		addedIdx []int
+		modified []*object.Change
+	)
+	for _, change := range changes {
+		fc := fileChange{}
+		switch {
+		case change.From.Name == "":
+			fc.Action = GitFileActionAdded
+			fc.Path = change.To.Name
+			added = append(added, change)
+			addedIdx = append(addedIdx, len(files))
+		case change.To.Name == "":
+			fc.Action = GitFileActionDeleted
+			fc.Path = change.From.Name
+		case change.From.Name != change.To.Name:
+			fc.Action = GitFileActionRenamed
+			fc.Path = change.To.Name
+			fc.OldPath = change.From.Name
+			fc.Similarity = changeSimilarity(change)
+		default:
+			fc.Action = GitFileActionModified
+			fc.Path = change.To.Name
+			modified = append(modified, change)
+		}
+		var ok bool
+		fc.Added, fc.Removed, ok = changeStats(change)
+		if !ok {
+			complete = false
+		}
+		files = append(files, fc)
+	}
+	detectCopies(files, addedIdx, added, modified)
+	return
+}
+
+// copySource - contents of a file before it was modified, read once for all added files compared to it
+type copySource struct {
+	path     string
+	hash     plumbing.Hash
+	contents string
+	text     bool
+}
+
+// detectCopies - mark added files similar enough to a file modified by the same commit as copied
+// Copy sources are limited to modified files, like git without --find-copies-harder, and nothing is
+// compared when there are more added or modified files than the rename limit
+func detectCopies(files []fileChange, addedIdx []int, added, modified []*object.Change) {
+	if len(added) == 0 || len(modified) == 0 || len(added) > GitRenameLimit || len(modified) > GitRenameLimit {
+		return
+	}
+	sources := make([]copySource, 0, len(modified))
+	for _, change := range modified {
+		from, _, err := change.Files()
+		if err != nil || from == nil {
+			continue
+		}
+		contents, ok := fileContents(from)
+		sources = append(sources, copySource{path: change.From.Name, hash: from.Hash, contents: contents, text: ok})
+	}
+	for i, change := range added {
+		_, dst, err := change.Files()
+		if err != nil || dst == nil {
+			continue
+		}
+		contents, text := fileContents(dst)
+		bestScore, bestPath := 0, ""
+		for _, src := range sources {
+			score := 0
+			switch {
+			case src.hash == dst.Hash:
+				score = 100
+			case src.text && text:
+				score = contentSimilarity(src.contents, contents)
+			}
+			if score > bestScore {
+				bestScore, bestPath = score, src.path
+			}
+		}
+		if bestScore >= GitSimilarityThreshold {
+			fc := &files[addedIdx[i]]
+			fc.Action = GitFileActionCopied
+			fc.OldPath = bestPath
+			fc.Similarity = bestScore
+		}
+	}
+}
+
+// changeStats - return lines added and removed by a single change, ok is false when file contents are missing
+func changeStats(change *object.Change) (added, removed int, ok bool) {
+	patch, err := change.Patch()
+	if err != nil {
+		return
+	}
+	for _, stat := range patch.Stats() {
+		added += stat.Addition
+		removed += stat.Deletion
+	}
+	ok = true
+	return
+}
+
+// changeSimilarity - return similarity percent of renamed file's old and new contents
+func changeSimilarity(change *object.Change) int {
+	if change.From.TreeEntry.Hash == change.To.TreeEntry.Hash {
+		return 100
+	}
+	from, to, err := change.Files()
+	if err != nil {
+		return 0
+	}
+	return fileSimilarity(from, to)
+}
+
+// fileSimilarity - return similarity percent of two files, computed as the number of bytes
+// in lines common to both files divided by the size of the larger file
+func fileSimilarity(a, b *object.File) int {
+	if a == nil || b == nil {
+		return 0
+	}
+	if a.Hash == b.Hash {
+		return 100
+	}
+	ac, ok := fileContents(a)
+	if !ok {
+		return 0
+	}
+	bc, ok := fileContents(b)
+	if !ok {
+		return 0
+	}
+	return contentSimilarity(ac, bc)
+}
+
+// fileContents - return text file contents, ok is false for binary files and files which cannot be read
+func fileContents(f *object.File) (contents string, ok bool) {
+	if bin, err := f.IsBinary(); err != nil || bin {
+		return
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return
+	}
+	ok = true
+	return
+}
+
+// contentSimilarity - return similarity percent of two texts, see fileSimilarity
+func contentSimilarity(ac, bc string) int {
+	maxSize := len(ac)
+	if len(bc) > maxSize {
+		maxSize = len(bc)
+	}
+	if maxSize == 0 {
+		return 100
+	}
+	lines := make(map[string]int)
+	for _, line := range strings.SplitAfter(ac, "\n") {
+		lines[line]++
+	}
+	common := 0
+	for _, line := range strings.SplitAfter(bc, "\n") {
+		if lines[line] > 0 {
+			lines[line]--
+			common += len(line)
+		}
+	}
+	return common * 100 / maxSize
+}
+
+// renamedFile - old path, new path and similarity of a renamed or copied file from rich item file data
+func renamedFile(fileData map[string]interface{}) CommitRenamedFile {
+	oldPath, _ := fileData["old_name"].(string)
+	newPath, _ := fileData["new_name"].(string)
+	similarity, _ := fileData["similarity"].(int)
+	return CommitRenamedFile{OldPath: oldPath, NewPath: newPath, Similarity: similarity}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	testFile       = "one1\ntwo2\nthr3\nfou4\n"
+	testFileEdited = "one1\ntwo2\nthr3\nxxx4\n"
+)
+
+func testFilesChanges(t *testing.T, tr *testRepo, parent, child plumbing.Hash) ([]fileChange, bool) {
+	var parents []plumbing.Hash
+	if !parent.IsZero() {
+		parents = append(parents, tr.commitTree("parent", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), parent))
+	}
+	hash := tr.commitTree("child", time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), child, parents...)
+	com, err := tr.r.CommitObject(hash)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, complete, err := getFilesChanges(context.Background(), *com)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, complete
+}
+
+func TestGetFilesChanges(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		parent   map[string]string
+		child    map[string]string
+		expected []fileChange
+	}{
+		{
+			name:     "root commit",
+			child:    map[string]string{"a.txt": testFile},
+			expected: []fileChange{{Action: GitFileActionAdded, Path: "a.txt", Added: 4}},
+		},
+		{
+			name:     "modified",
+			parent:   map[string]string{"a.txt": testFile},
+			child:    map[string]string{"a.txt": testFileEdited},
+			expected: []fileChange{{Action: GitFileActionModified, Path: "a.txt", Added: 1, Removed: 1}},
+		},
+		{
+			name:     "deleted",
+			parent:   map[string]string{"a.txt": testFile, "b.txt": "x\n"},
+			child:    map[string]string{"a.txt": testFile},
+			expected: []fileChange{{Action: GitFileActionDeleted, Path: "b.txt", Removed: 1}},
+		},
+		{
+			name:     "rename",
+			parent:   map[string]string{"a.txt": testFile},
+			child:    map[string]string{"b.txt": testFile},
+			expected: []fileChange{{Action: GitFileActionRenamed, Path: "b.txt", OldPath: "a.txt", Similarity: 100}},
+		},
+		{
+			name:     "rename with edits",
+			parent:   map[string]string{"a.txt": testFile},
+			child:    map[string]string{"b.txt": testFileEdited},
+			expected: []fileChange{{Action: GitFileActionRenamed, Path: "b.txt", OldPath: "a.txt", Similarity: 75, Added: 1, Removed: 1}},
+		},
+		{
+			name:   "copy of a modified file",
+			parent: map[string]string{"a.txt": testFile},
+			child:  map[string]string{"a.txt": testFileEdited, "c.txt": testFile + "new5\n"},
+			expected: []fileChange{
+				{Action: GitFileActionModified, Path: "a.txt", Added: 1, Removed: 1},
+				{Action: GitFileActionCopied, Path: "c.txt", OldPath: "a.txt", Similarity: 80, Added: 5},
+			},
+		},
+		{
+			name:   "exact copy of a modified file",
+			parent: map[string]string{"a.txt": testFile},
+			child:  map[string]string{"a.txt": testFileEdited, "c.txt": testFile},
+			expected: []fileChange{
+				{Action: GitFileActionModified, Path: "a.txt", Added: 1, Removed: 1},
+				{Action: GitFileActionCopied, Path: "c.txt", OldPath: "a.txt", Similarity: 100, Added: 4},
+			},
+		},
+		{
+			name:   "added file not similar to modified files",
+			parent: map[string]string{"a.txt": testFile},
+			child:  map[string]string{"a.txt": testFileEdited, "c.txt": "other\ntext\n"},
+			expected: []fileChange{
+				{Action: GitFileActionModified, Path: "a.txt", Added: 1, Removed: 1},
+				{Action: GitFileActionAdded, Path: "c.txt", Added: 2},
+			},
+		},
+		{
+			name:   "copy of an unmodified file is not detected",
+			parent: map[string]string{"a.txt": testFile, "b.txt": "x\n"},
+			child:  map[string]string{"a.txt": testFile, "b.txt": "y\n", "c.txt": testFile},
+			expected: []fileChange{
+				{Action: GitFileActionModified, Path: "b.txt", Added: 1, Removed: 1},
+				{Action: GitFileActionAdded, Path: "c.txt", Added: 4},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestRepo(t)
+			var parent plumbing.Hash
+			if tc.parent != nil {
+				parent = tr.files(tc.parent)
+			}
+			files, complete := testFilesChanges(t, tr, parent, tr.files(tc.child))
+			if !complete {
+				t.Errorf("expected complete changes")
+			}
+			if !reflect.DeepEqual(tc.expected, files) {
+				t.Errorf("expected %+v, got %+v", tc.expected, files)
+			}
+		})
+	}
+}
+
+func TestGetFilesChangesCopyLimit(t *testing.T) {
+	tr := newTestRepo(t)
+	child := map[string]string{"a.txt": testFileEdited, "c.txt": testFile}
+	for i := 0; i < GitRenameLimit; i++ {
+		child[fmt.Sprintf("f%04d.txt", i)] = fmt.Sprintf("file %d\n", i)
+	}
+	files, _ := testFilesChanges(t, tr, tr.files(map[string]string{"a.txt": testFile}), tr.files(child))
+	if len(files) != GitRenameLimit+2 {
+		t.Fatalf("expected %d files, got %d", GitRenameLimit+2, len(files))
+	}
+	for _, fc := range files {
+		if fc.Path == "c.txt" && fc.Action != GitFileActionAdded {
+			t.Errorf("expected no copy detection above the rename limit, got %+v", fc)
+		}
+	}
+}
+
+func TestGetFilesChangesPartialClone(t *testing.T) {
+	tr := newTestRepo(t)
+	// blobs of a blobless clone are not present in the repository
+	missing := func(name, contents string) plumbing.Hash {
+		hash := plumbing.ComputeHash(plumbing.BlobObject, []byte(contents))
+		return tr.store(&object.Tree{Entries: []object.TreeEntry{{Name: name, Mode: filemode.Regular, Hash: hash}}})
+	}
+	files, complete := testFilesChanges(t, tr, missing("a.txt", testFile), missing("b.txt", testFileEdited))
+	if complete {
+		t.Errorf("expected incomplete changes when file contents are missing")
+	}
+	expected := []fileChange{
+		{Action: GitFileActionDeleted, Path: "a.txt"},
+		{Action: GitFileActionAdded, Path: "b.txt"},
+	}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("expected %+v, got %+v", expected, files)
+	}
+}
+
+func TestFileSimilarity(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+	}{
+		{name: "same contents", a: testFile, b: testFile, expected: 100},
+		{name: "one of four lines changed", a: testFile, b: testFileEdited, expected: 75},
+		{name: "line appended", a: testFile, b: testFile + "new5\n", expected: 80},
+		{name: "half of the lines", a: "a\nb\n", b: "a\nc\n", expected: 50},
+		{name: "line order ignored", a: "a\nb\n", b: "b\na\n", expected: 100},
+		{name: "repeated line counted once", a: "a\na\n", b: "a\n", expected: 50},
+		{name: "nothing in common", a: "a\n", b: "b\n", expected: 0},
+		{name: "empty and non-empty", a: "", b: "a\n", expected: 0},
+		{name: "no trailing newline", a: "a\nb", b: "a\nb\n", expected: 50},
+		{name: "binary", a: "a\x00b", b: "a\x00c", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestRepo(t)
+			file := func(contents string) *object.File {
+				blob, err := tr.r.BlobObject(tr.blob(contents))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return object.NewFile("f", filemode.Regular, blob)
+			}
+			a, b := file(tc.a), file(tc.b)
+			if got := fileSimilarity(a, b); got != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, got)
+			}
+			if got := fileSimilarity(b, a); got != tc.expected {
+				t.Errorf("expected %d swapped, got %d", tc.expected, got)
+			}
+			if strings.ContainsRune(tc.a, 0) {
+				return
+			}
+			if got := contentSimilarity(tc.a, tc.b); got != tc.expected {
+				t.Errorf("expected %d comparing contents, got %d", tc.expected, got)
+			}
+		})
+	}
+	if got := fileSimilarity(nil, nil); got != 0 {
+		t.Errorf("expected 0 for missing files, got %d", got)
+	}
+}
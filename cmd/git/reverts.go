@@ -0,0 +1,42 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// GitCommitTypeRevert - commit reverting another commit (git revert)
+	GitCommitTypeRevert = "revert"
+	// GitCommitTypeCherryPick - commit cherry-picked from another commit (git cherry-pick -x), usually a backport
+	GitCommitTypeCherryPick = "cherry_pick"
+)
+
+var (
+	// GitRevertPattern - message line added by git revert
+	GitRevertPattern = regexp.MustCompile(`(?i)This reverts commit ([0-9a-f]{7,40})\b`)
+	// GitCherryPickPattern - message line added by git cherry-pick -x
+	GitCherryPickPattern = regexp.MustCompile(`(?i)\(cherry[ \-]picked from commit ([0-9a-f]{7,40})\)`)
+)
+
+// CommitOrigin - revert and cherry-pick detected from commit message
+// Type is cherry_pick when commit is a cherry-pick (also a cherry-picked revert), so backports can be counted once
+type CommitOrigin struct {
+	Type             string
+	RevertedSHA      string
+	CherryPickedFrom string
+}
+
+// DetectCommitOrigin - detect revert and cherry-pick, returns empty Type for other commits
+// Chained cherry-picks append one line each, the first one points to the original commit
+func DetectCommitOrigin(message string) (origin CommitOrigin) {
+	if m := GitRevertPattern.FindStringSubmatch(message); m != nil {
+		origin.Type = GitCommitTypeRevert
+		origin.RevertedSHA = strings.ToLower(m[1])
+	}
+	if m := GitCherryPickPattern.FindStringSubmatch(message); m != nil {
+		origin.Type = GitCommitTypeCherryPick
+		origin.CherryPickedFrom = strings.ToLower(m[1])
+	}
+	return
+}
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestDetectCommitOrigin(t *testing.T) {
+	const (
+		sha  = "0123456789abcdef0123456789abcdef01234567"
+		sha2 = "fedcba9876543210fedcba9876543210fedcba98"
+	)
+	var testCases = []struct {
+		name     string
+		message  string
+		expected CommitOrigin
+	}{
+		{
+			name:     "revert",
+			message:  "Revert \"Add tags sync\"\n\nThis reverts commit " + sha + ".",
+			expected: CommitOrigin{Type: GitCommitTypeRevert, RevertedSHA: sha},
+		},
+		{
+			name:     "revert of abbreviated SHA",
+			message:  "Revert \"Add tags sync\"\n\nThis reverts commit 0123abc.",
+			expected: CommitOrigin{Type: GitCommitTypeRevert, RevertedSHA: "0123abc"},
+		},
+		{
+			name:     "revert with upper case SHA",
+			message:  "this reverts commit 0123ABCDEF",
+			expected: CommitOrigin{Type: GitCommitTypeRevert, RevertedSHA: "0123abcdef"},
+		},
+		{
+			name:     "cherry-pick",
+			message:  "Fix crash\n\n(cherry picked from commit " + sha + ")",
+			expected: CommitOrigin{Type: GitCommitTypeCherryPick, CherryPickedFrom: sha},
+		},
+		{
+			name:     "cherry-pick with hyphen",
+			message:  "Fix crash\n\n(cherry-picked from commit 0123abc)",
+			expected: CommitOrigin{Type: GitCommitTypeCherryPick, CherryPickedFrom: "0123abc"},
+		},
+		{
+			name:     "chained cherry-picks point to the original commit",
+			message:  "Fix crash\n\n(cherry picked from commit " + sha + ")\n(cherry picked from commit " + sha2 + ")",
+			expected: CommitOrigin{Type: GitCommitTypeCherryPick, CherryPickedFrom: sha},
+		},
+		{
+			name:     "cherry-picked revert",
+			message:  "Revert \"Fix crash\"\n\nThis reverts commit " + sha + ".\n\n(cherry picked from commit " + sha2 + ")",
+			expected: CommitOrigin{Type: GitCommitTypeCherryPick, RevertedSHA: sha, CherryPickedFrom: sha2},
+		},
+		{
+			name:    "SHA too short",
+			message: "This reverts commit 012345.\n(cherry picked from commit 012345)",
+		},
+		{
+			name:    "SHA too long",
+			message: "This reverts commit " + sha + "8.\n(cherry picked from commit " + sha + "8)",
+		},
+		{
+			name:    "not a hex SHA",
+			message: "This reverts commit xyz1234567.\n(cherry picked from commit 0123abg)",
+		},
+		{
+			name:    "cherry-pick without parentheses",
+			message: "cherry picked from commit " + sha,
+		},
+		{
+			name:    "revert without SHA",
+			message: "Revert \"Add tags sync\"\n\nThis reverts the previous commit.",
+		},
+		{
+			name:    "other commit",
+			message: "Add tags sync",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := DetectCommitOrigin(tc.message); got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
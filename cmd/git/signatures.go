@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgpErrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// GitSignatureGPG - OpenPGP signed commit (gpg.format=openpgp)
+	GitSignatureGPG = "gpg"
+	// GitSignatureSSH - SSH signed commit (gpg.format=ssh)
+	GitSignatureSSH = "ssh"
+	// GitSignatureX509 - S/MIME signed commit (gpg.format=x509), reported but never verified
+	GitSignatureX509 = "x509"
+	// GitSignatureUnsigned - commit has no signature
+	GitSignatureUnsigned = "unsigned"
+	// GitSignatureUnverified - commit is signed, but no keyring or allowed signers file is configured for its type
+	GitSignatureUnverified = "unverified"
+	// GitSignatureGood - signature is valid and made by a trusted key
+	GitSignatureGood = "good"
+	// GitSignatureBad - signature does not match commit contents
+	GitSignatureBad = "bad"
+	// GitSignatureUnknownKey - signature was made by a key not present in keyring or allowed signers file
+	GitSignatureUnknownKey = "unknown_key"
+	// GitSignatureError - signature cannot be parsed
+	GitSignatureError = "error"
+	// sshSigMagic - SSH signature preamble, see PROTOCOL.sshsig
+	sshSigMagic = "SSHSIG"
+	// sshSigNamespace - namespace used by git for SSH commit signatures
+	sshSigNamespace = "git"
+)
+
+// CommitSignature - commit signature type, signing key and verification status
+type CommitSignature struct {
+	Signed bool   `json:"signed"`
+	Type   string `json:"type,omitempty"`
+	KeyID  string `json:"key_id,omitempty"`
+	Signer string `json:"signer,omitempty"`
+	Status string `json:"status"`
+}
+
+// allowedSigner - single allowed signers file entry, see ssh-keygen(1) ALLOWED SIGNERS
+type allowedSigner struct {
+	principals    []string
+	key           ssh.PublicKey
+	certAuthority bool      // key is a certificate authority, it never signs commits directly
+	namespaces    []string  // namespace patterns the key is allowed to sign, any namespace when empty
+	validAfter    time.Time // key is only trusted for commits made after this time, when set
+	validBefore   time.Time // key is only trusted for commits made before this time, when set
+}
+
+// SignatureVerifier - verify commit signatures against GPG keyring and SSH allowed signers file
+type SignatureVerifier struct {
+	keyring        openpgp.EntityList
+	allowedSigners []allowedSigner
+}
+
+// sshSignature - SSH signature blob without magic preamble
+type sshSignature struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData - data actually signed by SSH signature
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// NewSignatureVerifier - load armored or binary GPG keyring and SSH allowed signers file, both are optional
+func NewSignatureVerifier(keyringPath, allowedSignersPath string) (v *SignatureVerifier, err error) {
+	v = &SignatureVerifier{}
+	if keyringPath != "" {
+		var data []byte
+		data, err = os.ReadFile(keyringPath)
+		if err != nil {
+			return
+		}
+		v.keyring, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		if err != nil {
+			v.keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			err = fmt.Errorf("cannot read GPG keyring %s: %+v", keyringPath, err)
+			return
+		}
+	}
+	if allowedSignersPath != "" {
+		var data []byte
+		data, err = os.ReadFile(allowedSignersPath)
+		if err != nil {
+			return
+		}
+		v.allowedSigners, err = parseAllowedSigners(data)
+		if err != nil {
+			err = fmt.Errorf("cannot read SSH allowed signers %s: %+v", allowedSignersPath, err)
+			return
+		}
+	}
+	return
+}
+
+// parseAllowedSigners - parse "principals [options] keytype key [comment]" lines, fields are separated by any whitespace
+func parseAllowedSigners(data []byte) (signers []allowedSigner, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// principals can be quoted
+		end := strings.IndexAny(line, " \t")
+		if strings.HasPrefix(line, `"`) {
+			if end = strings.Index(line[1:], `"`); end >= 0 {
+				end += 2
+			}
+		}
+		if end <= 0 || end >= len(line) {
+			err = fmt.Errorf("line %d: missing public key", n)
+			return
+		}
+		signer := allowedSigner{principals: strings.Split(strings.Trim(line[:end], `"`), ",")}
+		// options before the key are parsed by ssh the same way as in authorized_keys
+		key, _, options, _, e := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(line[end:])))
+		if e != nil {
+			err = fmt.Errorf("line %d: %+v", n, e)
+			return
+		}
+		signer.key = key
+		if e := signer.parseOptions(options); e != nil {
+			err = fmt.Errorf("line %d: %+v", n, e)
+			return
+		}
+		signers = append(signers, signer)
+	}
+	err = scanner.Err()
+	return
+}
+
+// parseOptions - parse cert-authority, namespaces, valid-after and valid-before options, other options are rejected like ssh-keygen does
+func (s *allowedSigner) parseOptions(options []string) (err error) {
+	for _, option := range options {
+		name, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			name, value = option[:i], strings.Trim(option[i+1:], `"`)
+		}
+		switch strings.ToLower(name) {
+		case "cert-authority":
+			s.certAuthority = true
+		case "namespaces":
+			s.namespaces = strings.Split(value, ",")
+		case "valid-after":
+			s.validAfter, err = parseSSHTime(value)
+		case "valid-before":
+			s.validBefore, err = parseSSHTime(value)
+		default:
+			err = fmt.Errorf("unsupported option %s", name)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// parseSSHTime - parse YYYYMMDD[HHMM[SS]] time of allowed signers options, local time unless it ends with Z
+func parseSSHTime(value string) (time.Time, error) {
+	loc := time.Local
+	if strings.HasSuffix(value, "Z") || strings.HasSuffix(value, "z") {
+		value = value[:len(value)-1]
+		loc = time.UTC
+	}
+	for _, format := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(value) != len(format) {
+			continue
+		}
+		return time.ParseInLocation(format, value, loc)
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s", value)
+}
+
+// allows - is the key trusted to sign in a namespace at a given time
+func (s *allowedSigner) allows(namespace string, at time.Time) bool {
+	if !s.validAfter.IsZero() && at.Before(s.validAfter) {
+		return false
+	}
+	if !s.validBefore.IsZero() && at.After(s.validBefore) {
+		return false
+	}
+	if len(s.namespaces) == 0 {
+		return true
+	}
+	allowed := false
+	for _, pattern := range s.namespaces {
+		negated := strings.HasPrefix(pattern, "!")
+		if ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), namespace); ok {
+			if negated {
+				return false
+			}
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// Verify - return signature type, key ID and verification status of a commit
+func (v *SignatureVerifier) Verify(c *object.Commit) (sig CommitSignature) {
+	armored := strings.TrimSpace(c.PGPSignature)
+	if armored == "" {
+		sig.Status = GitSignatureUnsigned
+		return
+	}
+	sig.Signed = true
+	switch {
+	case strings.HasPrefix(armored, "-----BEGIN PGP SIGNATURE-----"):
+		sig.Type = GitSignatureGPG
+		v.verifyGPG(c, armored, &sig)
+	case strings.HasPrefix(armored, "-----BEGIN SSH SIGNATURE-----"):
+		sig.Type = GitSignatureSSH
+		v.verifySSH(c, armored, &sig)
+	default:
+		sig.Type = GitSignatureX509
+		sig.Status = GitSignatureUnverified
+	}
+	return
+}
+
+// signedPayload - commit object encoded without its signature, this is what was signed
+func signedPayload(c *object.Commit) ([]byte, error) {
+	encoded := &plumbing.MemoryObject{}
+	if err := c.EncodeWithoutSignature(encoded); err != nil {
+		return nil, err
+	}
+	r, err := encoded.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+	return io.ReadAll(r)
+}
+
+// verifyGPG - read issuer key ID from signature packet and check it against keyring
+func (v *SignatureVerifier) verifyGPG(c *object.Commit, armored string, sig *CommitSignature) {
+	block, err := armor.Decode(strings.NewReader(armored))
+	if err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	if s, ok := p.(*packet.Signature); ok && s.IssuerKeyId != nil {
+		sig.KeyID = fmt.Sprintf("%016X", *s.IssuerKeyId)
+	}
+	if len(v.keyring) == 0 {
+		sig.Status = GitSignatureUnverified
+		return
+	}
+	payload, err := signedPayload(c)
+	if err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	signer, err := openpgp.CheckArmoredDetachedSignature(v.keyring, bytes.NewReader(payload), strings.NewReader(armored), nil)
+	switch {
+	case err == pgpErrors.ErrUnknownIssuer:
+		sig.Status = GitSignatureUnknownKey
+	case err != nil:
+		sig.Status = GitSignatureBad
+	default:
+		sig.Status = GitSignatureGood
+		if identity := signer.PrimaryIdentity(); identity != nil {
+			sig.Signer = identity.Name
+		}
+	}
+}
+
+// verifySSH - parse SSH signature, its key fingerprint is the key ID, check key against allowed signers
+func (v *SignatureVerifier) verifySSH(c *object.Commit, armored string, sig *CommitSignature) {
+	lines := strings.Split(armored, "\n")
+	b64 := ""
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "-----END") {
+			break
+		}
+		b64 += line
+	}
+	blob, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || !bytes.HasPrefix(blob, []byte(sshSigMagic)) {
+		sig.Status = GitSignatureError
+		return
+	}
+	var s sshSignature
+	if err = ssh.Unmarshal(blob[len(sshSigMagic):], &s); err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	key, err := ssh.ParsePublicKey(s.PublicKey)
+	if err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	sig.KeyID = ssh.FingerprintSHA256(key)
+	if len(v.allowedSigners) == 0 {
+		sig.Status = GitSignatureUnverified
+		return
+	}
+	if _, ok := key.(*ssh.Certificate); ok {
+		// certificates signed by cert-authority keys are not validated
+		sig.Status = GitSignatureUnverified
+		return
+	}
+	var principals []string
+	for i := range v.allowedSigners {
+		signer := &v.allowedSigners[i]
+		if !signer.certAuthority && bytes.Equal(signer.key.Marshal(), key.Marshal()) && signer.allows(sshSigNamespace, c.Committer.When) {
+			principals = signer.principals
+			break
+		}
+	}
+	if principals == nil {
+		sig.Status = GitSignatureUnknownKey
+		return
+	}
+	var h hash.Hash
+	switch s.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		sig.Status = GitSignatureError
+		return
+	}
+	payload, err := signedPayload(c)
+	if err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	_, _ = h.Write(payload)
+	signed := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{
+		Namespace:     s.Namespace,
+		Reserved:      s.Reserved,
+		HashAlgorithm: s.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	signature := &ssh.Signature{}
+	if err = ssh.Unmarshal(s.Signature, signature); err != nil {
+		sig.Status = GitSignatureError
+		return
+	}
+	if s.Namespace != sshSigNamespace || key.Verify(signed, signature) != nil {
+		sig.Status = GitSignatureBad
+		return
+	}
+	sig.Status = GitSignatureGood
+	sig.Signer = strings.Join(principals, ",")
+}
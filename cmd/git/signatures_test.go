@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHKey - generate ed25519 SSH signer and its authorized key line
+func testSSHKey(t *testing.T) (ssh.Signer, string) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("ssh signer: %v", err)
+	}
+	return signer, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+// testCommit - commit made at a given time, not stored in any repository
+func testCommit(when time.Time) *object.Commit {
+	sig := object.Signature{Name: "Jane Doe", Email: "jane@example.com", When: when}
+	return &object.Commit{Author: sig, Committer: sig, Message: "signed\n"}
+}
+
+// sshSign - sign commit payload the way `git commit -S` with gpg.format=ssh does, see PROTOCOL.sshsig
+func sshSign(t *testing.T, c *object.Commit, signer ssh.Signer, namespace string) {
+	payload, err := signedPayload(c)
+	if err != nil {
+		t.Fatalf("commit payload: %v", err)
+	}
+	h := sha512.Sum512(payload)
+	signed := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{Namespace: namespace, HashAlgorithm: "sha512", Hash: h[:]})...)
+	signature, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	blob := append([]byte(sshSigMagic), ssh.Marshal(sshSignature{
+		Version:       1,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(signature),
+	})...)
+	b64 := base64.StdEncoding.EncodeToString(blob)
+	armored := "-----BEGIN SSH SIGNATURE-----\n"
+	for len(b64) > 70 {
+		armored += b64[:70] + "\n"
+		b64 = b64[70:]
+	}
+	c.PGPSignature = armored + b64 + "\n-----END SSH SIGNATURE-----\n"
+}
+
+func TestVerifySSH(t *testing.T) {
+	signer, key := testSSHKey(t)
+	_, otherKey := testSSHKey(t)
+	when := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name           string
+		allowedSigners string
+		namespace      string
+		tamper         bool
+		status         string
+		signer         string
+	}{
+		{
+			name:           "good",
+			allowedSigners: "jane@example.com " + key,
+			status:         GitSignatureGood,
+			signer:         "jane@example.com",
+		},
+		{
+			name:           "good with many principals",
+			allowedSigners: "jane@example.com,jd@example.com " + key,
+			status:         GitSignatureGood,
+			signer:         "jane@example.com,jd@example.com",
+		},
+		{
+			name:           "tampered payload",
+			allowedSigners: "jane@example.com " + key,
+			tamper:         true,
+			status:         GitSignatureBad,
+		},
+		{
+			name:           "wrong namespace",
+			allowedSigners: "jane@example.com " + key,
+			namespace:      "file",
+			status:         GitSignatureBad,
+		},
+		{
+			name:           "unknown key",
+			allowedSigners: "jane@example.com " + otherKey,
+			status:         GitSignatureUnknownKey,
+		},
+		{
+			name:           "no allowed signers",
+			allowedSigners: "",
+			status:         GitSignatureUnverified,
+		},
+		{
+			name:           "valid after commit",
+			allowedSigners: `jane@example.com valid-after="20220701Z" ` + key,
+			status:         GitSignatureUnknownKey,
+		},
+		{
+			name:           "valid before commit",
+			allowedSigners: `jane@example.com valid-before="20220501Z" ` + key,
+			status:         GitSignatureUnknownKey,
+		},
+		{
+			name:           "valid during commit",
+			allowedSigners: `jane@example.com valid-after="20220501Z",valid-before="202207011200Z" ` + key,
+			status:         GitSignatureGood,
+			signer:         "jane@example.com",
+		},
+		{
+			name:           "namespace not allowed",
+			allowedSigners: `jane@example.com namespaces="file" ` + key,
+			status:         GitSignatureUnknownKey,
+		},
+		{
+			name:           "namespace allowed",
+			allowedSigners: `jane@example.com namespaces="file,git" ` + key,
+			status:         GitSignatureGood,
+			signer:         "jane@example.com",
+		},
+		{
+			name:           "cert authority key",
+			allowedSigners: "*@example.com cert-authority " + key,
+			status:         GitSignatureUnknownKey,
+		},
+		{
+			name:           "second entry matches",
+			allowedSigners: "john@example.com " + otherKey + "\njane@example.com " + key,
+			status:         GitSignatureGood,
+			signer:         "jane@example.com",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signers, err := parseAllowedSigners([]byte(tc.allowedSigners))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			v := &SignatureVerifier{allowedSigners: signers}
+			namespace := tc.namespace
+			if namespace == "" {
+				namespace = sshSigNamespace
+			}
+			c := testCommit(when)
+			sshSign(t, c, signer, namespace)
+			if tc.tamper {
+				c.Message = "tampered\n"
+			}
+			expected := CommitSignature{
+				Signed: true,
+				Type:   GitSignatureSSH,
+				KeyID:  ssh.FingerprintSHA256(signer.PublicKey()),
+				Signer: tc.signer,
+				Status: tc.status,
+			}
+			if got := v.Verify(c); got != expected {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+		})
+	}
+}
+
+func TestVerifyUnsignedAndUnknownFormats(t *testing.T) {
+	var testCases = []struct {
+		name      string
+		signature string
+		expected  CommitSignature
+	}{
+		{
+			name:     "unsigned",
+			expected: CommitSignature{Status: GitSignatureUnsigned},
+		},
+		{
+			name:      "x509",
+			signature: "-----BEGIN SIGNED MESSAGE-----\nMIIB\n-----END SIGNED MESSAGE-----\n",
+			expected:  CommitSignature{Signed: true, Type: GitSignatureX509, Status: GitSignatureUnverified},
+		},
+		{
+			name:      "malformed SSH signature",
+			signature: "-----BEGIN SSH SIGNATURE-----\nnot base64\n-----END SSH SIGNATURE-----\n",
+			expected:  CommitSignature{Signed: true, Type: GitSignatureSSH, Status: GitSignatureError},
+		},
+		{
+			name:      "SSH signature without magic preamble",
+			signature: "-----BEGIN SSH SIGNATURE-----\n" + base64.StdEncoding.EncodeToString([]byte("NOTSIG")) + "\n-----END SSH SIGNATURE-----\n",
+			expected:  CommitSignature{Signed: true, Type: GitSignatureSSH, Status: GitSignatureError},
+		},
+		{
+			name:      "malformed PGP signature",
+			signature: "-----BEGIN PGP SIGNATURE-----\n\nbm90IGEgcGFja2V0\n-----END PGP SIGNATURE-----\n",
+			expected:  CommitSignature{Signed: true, Type: GitSignatureGPG, Status: GitSignatureError},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testCommit(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+			c.PGPSignature = tc.signature
+			if got := (&SignatureVerifier{}).Verify(c); got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestVerifyGPG(t *testing.T) {
+	config := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity("Jane Doe", "", "jane@example.com", config)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	other, err := openpgp.NewEntity("John Doe", "", "john@example.com", config)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	c := testCommit(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+	payload, err := signedPayload(c)
+	if err != nil {
+		t.Fatalf("commit payload: %v", err)
+	}
+	var armored bytes.Buffer
+	if err = openpgp.ArmoredDetachSign(&armored, entity, bytes.NewReader(payload), config); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	c.PGPSignature = armored.String()
+	keyID := entity.PrimaryKey.KeyIdString()
+	var testCases = []struct {
+		name     string
+		keyring  openpgp.EntityList
+		expected CommitSignature
+	}{
+		{
+			name:     "good",
+			keyring:  openpgp.EntityList{other, entity},
+			expected: CommitSignature{Signed: true, Type: GitSignatureGPG, KeyID: keyID, Signer: "Jane Doe <jane@example.com>", Status: GitSignatureGood},
+		},
+		{
+			name:     "unknown issuer",
+			keyring:  openpgp.EntityList{other},
+			expected: CommitSignature{Signed: true, Type: GitSignatureGPG, KeyID: keyID, Status: GitSignatureUnknownKey},
+		},
+		{
+			name:     "no keyring",
+			expected: CommitSignature{Signed: true, Type: GitSignatureGPG, KeyID: keyID, Status: GitSignatureUnverified},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &SignatureVerifier{keyring: tc.keyring}
+			if got := v.Verify(c); got != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseAllowedSigners(t *testing.T) {
+	_, key := testSSHKey(t)
+	fields := strings.Fields(key)
+	var testCases = []struct {
+		name     string
+		data     string
+		expected []allowedSigner
+		err      bool
+	}{
+		{
+			name:     "single principal",
+			data:     "jane@example.com " + key,
+			expected: []allowedSigner{{principals: []string{"jane@example.com"}}},
+		},
+		{
+			name:     "many principals",
+			data:     "jane@example.com,jd@example.com " + key,
+			expected: []allowedSigner{{principals: []string{"jane@example.com", "jd@example.com"}}},
+		},
+		{
+			name:     "quoted principals",
+			data:     `"Jane Doe,jane@example.com" ` + key,
+			expected: []allowedSigner{{principals: []string{"Jane Doe", "jane@example.com"}}},
+		},
+		{
+			name:     "tabs and comment after key",
+			data:     "jane@example.com\t" + fields[0] + "\t" + fields[1] + " laptop key",
+			expected: []allowedSigner{{principals: []string{"jane@example.com"}}},
+		},
+		{
+			name:     "comments and blank lines",
+			data:     "# allowed signers\n\n  \njane@example.com " + key + "\n# john@example.com " + key + "\n",
+			expected: []allowedSigner{{principals: []string{"jane@example.com"}}},
+		},
+		{
+			name: "options",
+			data: `jane@example.com namespaces="git,file",valid-after="20220101Z",valid-before="202301021504Z" ` + key,
+			expected: []allowedSigner{{
+				principals:  []string{"jane@example.com"},
+				namespaces:  []string{"git", "file"},
+				validAfter:  time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				validBefore: time.Date(2023, 1, 2, 15, 4, 0, 0, time.UTC),
+			}},
+		},
+		{
+			name:     "cert authority",
+			data:     "*@example.com cert-authority " + key,
+			expected: []allowedSigner{{principals: []string{"*@example.com"}, certAuthority: true}},
+		},
+		{
+			name: "missing key",
+			data: "jane@example.com",
+			err:  true,
+		},
+		{
+			name: "unterminated quote",
+			data: `"jane@example.com ` + key,
+			err:  true,
+		},
+		{
+			name: "invalid key",
+			data: "jane@example.com ssh-ed25519 bm90IGEga2V5",
+			err:  true,
+		},
+		{
+			name: "unsupported option",
+			data: "jane@example.com no-touch-required " + key,
+			err:  true,
+		},
+		{
+			name: "invalid time",
+			data: `jane@example.com valid-after="2022" ` + key,
+			err:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signers, err := parseAllowedSigners([]byte(tc.data))
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected error, got %+v", signers)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(signers) != len(tc.expected) {
+				t.Fatalf("expected %d signers, got %d", len(tc.expected), len(signers))
+			}
+			for i := range signers {
+				if got := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signers[i].key))); got != key {
+					t.Errorf("expected key %s, got %s", key, got)
+				}
+				signers[i].key = nil
+				if !reflect.DeepEqual(tc.expected[i], signers[i]) {
+					t.Errorf("expected %+v, got %+v", tc.expected[i], signers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSSHTime(t *testing.T) {
+	var testCases = []struct {
+		value    string
+		expected time.Time
+		err      bool
+	}{
+		{value: "20220102", expected: time.Date(2022, 1, 2, 0, 0, 0, 0, time.Local)},
+		{value: "202201021504", expected: time.Date(2022, 1, 2, 15, 4, 0, 0, time.Local)},
+		{value: "20220102150405", expected: time.Date(2022, 1, 2, 15, 4, 5, 0, time.Local)},
+		{value: "20220102Z", expected: time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{value: "20220102150405z", expected: time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{value: "", err: true},
+		{value: "2022", err: true},
+		{value: "2022-01-02", err: true},
+		{value: "20221302", err: true},
+	}
+	for _, tc := range testCases {
+		got, err := parseSSHTime(tc.value)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected error, got %v", tc.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.value, err)
+			continue
+		}
+		if !got.Equal(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestAllowedSignerAllows(t *testing.T) {
+	at := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		signer   allowedSigner
+		expected bool
+	}{
+		{name: "no restrictions", expected: true},
+		{name: "namespace", signer: allowedSigner{namespaces: []string{"git"}}, expected: true},
+		{name: "other namespace", signer: allowedSigner{namespaces: []string{"file"}}, expected: false},
+		{name: "one of namespaces", signer: allowedSigner{namespaces: []string{"file", "git"}}, expected: true},
+		{name: "namespace pattern", signer: allowedSigner{namespaces: []string{"g?t"}}, expected: true},
+		{name: "any namespace", signer: allowedSigner{namespaces: []string{"*"}}, expected: true},
+		{name: "negated namespace", signer: allowedSigner{namespaces: []string{"*", "!git"}}, expected: false},
+		{name: "negated other namespace", signer: allowedSigner{namespaces: []string{"*", "!file"}}, expected: true},
+		{name: "valid after", signer: allowedSigner{validAfter: at.Add(-time.Hour)}, expected: true},
+		{name: "not valid yet", signer: allowedSigner{validAfter: at.Add(time.Hour)}, expected: false},
+		{name: "valid before", signer: allowedSigner{validBefore: at.Add(time.Hour)}, expected: true},
+		{name: "expired", signer: allowedSigner{validBefore: at.Add(-time.Hour)}, expected: false},
+		{name: "valid at boundaries", signer: allowedSigner{validAfter: at, validBefore: at}, expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.signer.allows(sshSigNamespace, at); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	"github.com/LF-Engineering/lfx-event-schema/service"
+	"github.com/LF-Engineering/lfx-event-schema/service/insights"
+	"github.com/LF-Engineering/lfx-event-schema/service/insights/git"
+	"github.com/LF-Engineering/lfx-event-schema/service/repository"
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RepositoryStatsCreated repository stats snapshot created event
+	RepositoryStatsCreated = "repository_stats.created"
+	// RepositoryStatsUpdated repository stats snapshot updated event (current period got new commits)
+	RepositoryStatsUpdated = "repository_stats.updated"
+	// statsCacheFile - repository stats snapshots cache file name, same format as commits cache
+	statsCacheFile = "stats-cache.csv"
+	// GitStatsPeriodFormat - snapshots are taken at the last commit of each month
+	GitStatsPeriodFormat = "2006-01"
+	// GitDefaultStatsMonths - default number of most recent months with snapshots, so the first sync does not count the whole history
+	GitDefaultStatsMonths = 24
+)
+
+// RepositoryStats - lines of code and language mix of the repository at a given commit
+type RepositoryStats struct {
+	ID                string    `json:"repository_stats_id"`
+	RepositoryURL     string    `json:"repository_url"`
+	RepositoryID      string    `json:"repository_id"`
+	Period            string    `json:"period"`
+	SHA               string    `json:"sha"`
+	SnapshotTimestamp time.Time `json:"snapshot_timestamp"`
+	LinesOfCode       int       `json:"lines_of_code"`
+	CommentLines      int       `json:"comment_lines"`
+	BlankLines        int       `json:"blank_lines"`
+	Files             int       `json:"files"`
+	Languages         []PLS     `json:"languages"`
+	SyncTimestamp     time.Time `json:"sync_timestamp"`
+}
+
+// RepositoryStatsCreatedEvent - repository_stats.created event
+type RepositoryStatsCreatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload RepositoryStats `json:"payload,omitempty"`
+}
+
+// RepositoryStatsUpdatedEvent - repository_stats.updated event
+type RepositoryStatsUpdatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload RepositoryStats `json:"payload,omitempty"`
+}
+
+// getStatsCheckpoints - return the last commit of each month reachable from HEAD, newest first
+// commits are walked backward in committer time order, so the first commit seen in a month is its last one
+func getStatsCheckpoints(r *goGit.Repository, since time.Time) (checkpoints []*object.Commit, err error) {
+	ref, err := r.Head()
+	if err != nil {
+		return
+	}
+	cIter, err := r.Log(&goGit.LogOptions{From: ref.Hash(), Order: goGit.LogOrderCommitterTime})
+	if err != nil {
+		return
+	}
+	defer cIter.Close()
+	seen := make(map[string]struct{})
+	err = cIter.ForEach(func(c *object.Commit) error {
+		when := c.Committer.When.UTC()
+		if when.Before(since) {
+			return storer.ErrStop
+		}
+		period := when.Format(GitStatsPeriodFormat)
+		if _, ok := seen[period]; ok {
+			return nil
+		}
+		seen[period] = struct{}{}
+		checkpoints = append(checkpoints, c)
+		return nil
+	})
+	// history of a shallow clone ends at its boundary commits
+	if isMissingObject(err) {
+		err = nil
+	}
+	return
+}
+
+// statsSince - return the oldest commit date with a snapshot: start of the month months-1 months before now, or date from when later
+// months 0 means no limit
+func statsSince(dateFrom *time.Time, months int, now time.Time) (since time.Time) {
+	if months > 0 {
+		now = now.UTC()
+		since = time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if dateFrom != nil && dateFrom.After(since) {
+		since = *dateFrom
+	}
+	return
+}
+
+// SyncStats - emit repository stats snapshot events for the last commit of each month of the last StatsMonths months
+// Snapshots whose checkpoint commit did not change since the previous sync are not recounted
+func (j *DSGit) SyncStats(ctx *shared.Ctx, r *goGit.Repository) (err error) {
+	repoID, err := repository.GenerateRepositoryID(j.SourceID, j.URL, j.RepositorySource)
+	if err != nil {
+		return
+	}
+	since := statsSince(ctx.DateFrom, j.StatsMonths, time.Now())
+	checkpoints, err := getStatsCheckpoints(r, since)
+	if err != nil {
+		return
+	}
+	cached := j.getCacheFileByEntityID(statsCacheFile)
+	baseEvent := service.BaseEvent{
+		CRUDInfo: service.CRUDInfo{
+			CreatedBy: GitConnector,
+			UpdatedBy: GitConnector,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+	statsBaseEvent := git.CommitBaseEvent{
+		Connector:        insights.GitConnector,
+		ConnectorVersion: GitBackendVersion,
+		Source:           insights.Source(j.RepositorySource),
+	}
+	createdData, updatedData := make([]interface{}, 0), make([]interface{}, 0)
+	createdCache, updatedCache := make([]CommitCache, 0), make([]CommitCache, 0)
+	counter := newLocCounter()
+	for _, c := range checkpoints {
+		period := c.Committer.When.UTC().Format(GitStatsPeriodFormat)
+		id := fmt.Sprintf("%x", sha256.Sum256([]byte(repoID+":"+period)))
+		sha := c.Hash.String()
+		prev, ok := cached[id]
+		if ok && prev.SourceEntityID == sha {
+			continue
+		}
+		tree, e := c.Tree()
+		if e != nil {
+			err = e
+			return
+		}
+		res, e := counter.CountTree(tree)
+		if e != nil {
+			err = e
+			return
+		}
+		stats := RepositoryStats{
+			ID:                id,
+			RepositoryURL:     shared.AnonymizeURL(j.URL),
+			RepositoryID:      repoID,
+			Period:            period,
+			SHA:               sha,
+			SnapshotTimestamp: c.Committer.When,
+			LinesOfCode:       res[LocSumKey].Code,
+			CommentLines:      res[LocSumKey].Comment,
+			BlankLines:        res[LocSumKey].Blank,
+			Files:             res[LocSumKey].NumberOfFiles,
+			SyncTimestamp:     time.Now(),
+		}
+		_, stats.Languages = locSummary(res)
+		// cache file entries are read keyed by hash, so it includes snapshot ID and SHA: periods with the same languages must not collide
+		b, e := jsoniter.Marshal(stats.Languages)
+		if e != nil {
+			err = e
+			return
+		}
+		comm := CommitCache{
+			Timestamp:      fmt.Sprintf("%v", stats.SyncTimestamp.Unix()),
+			EntityID:       id,
+			SourceEntityID: sha,
+			Hash:           fmt.Sprintf("%x", sha256.Sum256(append([]byte(id+":"+sha+":"), b...))),
+			CommitDate:     stats.SnapshotTimestamp,
+		}
+		if ok {
+			baseEvent.Type = RepositoryStatsUpdated
+			updatedData = append(updatedData, RepositoryStatsUpdatedEvent{CommitBaseEvent: statsBaseEvent, BaseEvent: baseEvent, Payload: stats})
+			updatedCache = append(updatedCache, comm)
+			continue
+		}
+		baseEvent.Type = RepositoryStatsCreated
+		createdData = append(createdData, RepositoryStatsCreatedEvent{CommitBaseEvent: statsBaseEvent, BaseEvent: baseEvent, Payload: stats})
+		createdCache = append(createdCache, comm)
+	}
+	if err = j.publishCachedEvents(ctx, statsCacheFile, RepositoryStatsCreated, "repository_stats", createdData, createdCache, cached); err != nil {
+		return
+	}
+	if err = j.publishCachedEvents(ctx, statsCacheFile, RepositoryStatsUpdated, "repository_stats", updatedData, updatedCache, cached); err != nil {
+		return
+	}
+	j.log.WithFields(logrus.Fields{"operation": "SyncStats"}).Infof("%d monthly snapshots: %d created, %d updated", len(checkpoints), len(createdData), len(updatedData))
+	return
+}
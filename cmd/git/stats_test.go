@@ -0,0 +1,201 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+)
+
+// testPublisher - Publisher recording published events by action
+type testPublisher struct {
+	events map[string][]interface{}
+}
+
+func (p *testPublisher) PushEvents(action, source, eventType, subEventType, env string, data []interface{}, endpoint string) (string, error) {
+	p.events[action] = append(p.events[action], data...)
+	return "path/" + action, nil
+}
+
+func TestStatsSince(t *testing.T) {
+	now := time.Date(2022, 3, 15, 10, 0, 0, 0, time.UTC)
+	dateFrom := time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC)
+	oldDateFrom := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var testCases = []struct {
+		name     string
+		dateFrom *time.Time
+		months   int
+		expected time.Time
+	}{
+		{name: "no limit", expected: time.Time{}},
+		{name: "no limit with date from", dateFrom: &dateFrom, expected: dateFrom},
+		{name: "current month only", months: 1, expected: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "months crossing year", months: 4, expected: time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "default", months: GitDefaultStatsMonths, expected: time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "later date from", dateFrom: &dateFrom, months: 4, expected: dateFrom},
+		{name: "earlier date from", dateFrom: &oldDateFrom, months: 4, expected: time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := statsSince(tc.dateFrom, tc.months, now); !got.Equal(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetStatsCheckpoints(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2022, month, d, 12, 0, 0, 0, time.UTC)
+	}
+	var testCases = []struct {
+		name  string
+		since time.Time
+		// setup builds the repository and returns expected checkpoints, newest first
+		setup func(tr *testRepo) []plumbing.Hash
+	}{
+		{
+			name: "single commit",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				tr.setRef("refs/heads/master", a)
+				return []plumbing.Hash{a}
+			},
+		},
+		{
+			name: "last commit of each month",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				b := tr.commitAt("b", day(1, 20), a)
+				c := tr.commitAt("c", day(2, 5), b)
+				d := tr.commitAt("d", day(4, 1), c)
+				e := tr.commitAt("e", day(4, 30), d)
+				tr.setRef("refs/heads/master", e)
+				return []plumbing.Hash{e, c, b}
+			},
+		},
+		{
+			name: "months are UTC",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				// 2022-02-01 01:00 in UTC+2 is still January in UTC
+				b := tr.commitAt("b", time.Date(2022, 2, 1, 1, 0, 0, 0, time.FixedZone("", 2*3600)), a)
+				tr.setRef("refs/heads/master", b)
+				return []plumbing.Hash{b}
+			},
+		},
+		{
+			name: "merged branch commit is the last one of its month",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				f := tr.commitAt("feature", day(1, 25), a)
+				b := tr.commitAt("b", day(1, 20), a)
+				m := tr.commitAt("merge", day(2, 1), b, f)
+				tr.setRef("refs/heads/master", m)
+				return []plumbing.Hash{m, f}
+			},
+		},
+		{
+			name:  "commits before since are skipped",
+			since: day(2, 1),
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				b := tr.commitAt("b", day(2, 5), a)
+				c := tr.commitAt("c", day(3, 5), b)
+				tr.setRef("refs/heads/master", c)
+				return []plumbing.Hash{c, b}
+			},
+		},
+		{
+			name: "branches other than HEAD are ignored",
+			setup: func(tr *testRepo) []plumbing.Hash {
+				a := tr.commitAt("a", day(1, 10))
+				tr.setRef("refs/heads/master", a)
+				tr.setRef("refs/heads/feature", tr.commitAt("feature", day(2, 10), a))
+				return []plumbing.Hash{a}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTestRepo(t)
+			expected := tc.setup(tr)
+			checkpoints, err := getStatsCheckpoints(tr.r, tc.since)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []plumbing.Hash
+			for _, c := range checkpoints {
+				got = append(got, c.Hash)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestSyncStats(t *testing.T) {
+	tr := newTestRepo(t)
+	jan := tr.commitTree("jan", time.Date(2022, 1, 20, 0, 0, 0, 0, time.UTC), tr.files(map[string]string{"main.go": "package main\n"}))
+	feb := tr.commitTree("feb", time.Date(2022, 2, 5, 0, 0, 0, 0, time.UTC), tr.files(map[string]string{"main.go": "package main\n", "a.py": "import os\n"}), jan)
+	tr.setRef("refs/heads/master", feb)
+	p := &testPublisher{}
+	j := &DSGit{
+		URL:              "https://github.com/org/repo",
+		RepositorySource: "git",
+		Publisher:        p,
+		log:              logrus.NewEntry(logrus.New()),
+		cacheProvider:    NewMemoryCache(),
+		endpoint:         "github.com-org-repo",
+	}
+	ctx := &shared.Ctx{PackSize: 1}
+	syncStats := func() (created, updated []RepositoryStats) {
+		p.events = make(map[string][]interface{})
+		if err := j.SyncStats(ctx, tr.r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, ev := range p.events[RepositoryStatsCreated] {
+			created = append(created, ev.(RepositoryStatsCreatedEvent).Payload)
+		}
+		for _, ev := range p.events[RepositoryStatsUpdated] {
+			updated = append(updated, ev.(RepositoryStatsUpdatedEvent).Payload)
+		}
+		return
+	}
+	created, updated := syncStats()
+	if len(created) != 2 || len(updated) != 0 {
+		t.Fatalf("expected 2 created and no updated snapshots, got %+v and %+v", created, updated)
+	}
+	if created[0].Period != "2022-02" || created[0].SHA != feb.String() || created[0].LinesOfCode != 2 || created[0].Files != 2 {
+		t.Errorf("unexpected February snapshot %+v", created[0])
+	}
+	if created[1].Period != "2022-01" || created[1].SHA != jan.String() || created[1].LinesOfCode != 1 || created[1].Files != 1 {
+		t.Errorf("unexpected January snapshot %+v", created[1])
+	}
+	if created[0].ID == created[1].ID {
+		t.Errorf("expected different snapshot IDs per period")
+	}
+	// checkpoint commits did not change
+	created, updated = syncStats()
+	if len(created) != 0 || len(updated) != 0 {
+		t.Errorf("expected no snapshots for unchanged commits, got %+v and %+v", created, updated)
+	}
+	// new commit in February replaces its checkpoint
+	feb2 := tr.commitTree("feb2", time.Date(2022, 2, 25, 0, 0, 0, 0, time.UTC), tr.files(map[string]string{"a.py": "import os\n"}), feb)
+	tr.setRef("refs/heads/master", feb2)
+	created, updated = syncStats()
+	if len(created) != 0 || len(updated) != 1 {
+		t.Fatalf("expected 1 updated snapshot, got %+v and %+v", created, updated)
+	}
+	if updated[0].Period != "2022-02" || updated[0].SHA != feb2.String() || updated[0].LinesOfCode != 1 {
+		t.Errorf("unexpected updated February snapshot %+v", updated[0])
+	}
+	created, updated = syncStats()
+	if len(created) != 0 || len(updated) != 0 {
+		t.Errorf("expected no snapshots after update, got %+v and %+v", created, updated)
+	}
+}
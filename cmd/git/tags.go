@@ -0,0 +1,299 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	"github.com/LF-Engineering/lfx-event-schema/service"
+	"github.com/LF-Engineering/lfx-event-schema/service/insights"
+	"github.com/LF-Engineering/lfx-event-schema/service/insights/git"
+	"github.com/LF-Engineering/lfx-event-schema/service/repository"
+	"github.com/LF-Engineering/lfx-event-schema/service/user"
+	goGit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TagCreated tag created event
+	TagCreated = "tag.created"
+	// TagUpdated tag updated event
+	TagUpdated = "tag.updated"
+	// tagsCacheFile - tags cache file name, same format as commits cache
+	tagsCacheFile = "tags-cache.csv"
+)
+
+var (
+	// GitSemVerPattern - semantic version 2.0.0 pattern, optionally prefixed with v
+	GitSemVerPattern = regexp.MustCompile(`^[vV]?(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<pre>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<build>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+)
+
+// SemVer - semantic version parsed from a tag name
+type SemVer struct {
+	Major      int    `json:"major"`
+	Minor      int    `json:"minor"`
+	Patch      int    `json:"patch"`
+	PreRelease string `json:"pre_release,omitempty"`
+	Build      string `json:"build,omitempty"`
+}
+
+// Tag - git tag payload
+type Tag struct {
+	ID              string                       `json:"tag_id"`
+	Name            string                       `json:"name"`
+	RepositoryURL   string                       `json:"repository_url"`
+	RepositoryID    string                       `json:"repository_id"`
+	TargetSHA       string                       `json:"target_sha"`
+	TargetType      string                       `json:"target_type"`
+	Annotated       bool                         `json:"annotated"`
+	Message         string                       `json:"message,omitempty"`
+	Tagger          *user.UserIdentityObjectBase `json:"tagger,omitempty"`
+	TaggedTimestamp time.Time                    `json:"tagged_timestamp"`
+	SemVer          *SemVer                      `json:"semver,omitempty"`
+	SyncTimestamp   time.Time                    `json:"sync_timestamp"`
+}
+
+// TagCreatedEvent - tag.created event
+type TagCreatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload Tag `json:"payload,omitempty"`
+}
+
+// TagUpdatedEvent - tag.updated event
+type TagUpdatedEvent struct {
+	git.CommitBaseEvent
+	service.BaseEvent
+	Payload Tag `json:"payload,omitempty"`
+}
+
+// ParseSemVer - parse semantic version from tag name, path prefixes like "module/v1.2.3" are skipped
+func ParseSemVer(name string) *SemVer {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	m := shared.MatchGroups(GitSemVerPattern, name)
+	if len(m) == 0 {
+		return nil
+	}
+	major, _ := strconv.Atoi(m["major"])
+	minor, _ := strconv.Atoi(m["minor"])
+	patch, _ := strconv.Atoi(m["patch"])
+	return &SemVer{
+		Major:      major,
+		Minor:      minor,
+		Patch:      patch,
+		PreRelease: m["pre"],
+		Build:      m["build"],
+	}
+}
+
+// GetTags - return all repository tags, annotated tags carry tagger and message
+// lightweight tags have no tagger object, so the target commit's committer is used instead
+func (j *DSGit) GetTags(ctx *shared.Ctx, r *goGit.Repository) (tags []Tag, err error) {
+	repoID, err := repository.GenerateRepositoryID(j.SourceID, j.URL, j.RepositorySource)
+	if err != nil {
+		return
+	}
+	refs, err := r.Tags()
+	if err != nil {
+		return
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag := Tag{
+			Name:          ref.Name().Short(),
+			RepositoryURL: shared.AnonymizeURL(j.URL),
+			RepositoryID:  repoID,
+			SyncTimestamp: time.Now(),
+		}
+		tag.ID = fmt.Sprintf("%x", sha256.Sum256([]byte(repoID+":"+tag.Name)))
+		tag.SemVer = ParseSemVer(tag.Name)
+		var signature object.Signature
+		tagObj, e := r.TagObject(ref.Hash())
+		switch e {
+		case nil:
+			tag.Annotated = true
+			tag.TargetSHA = tagObj.Target.String()
+			tag.TargetType = tagObj.TargetType.String()
+			tag.Message = strings.TrimSpace(tagObj.Message)
+			signature = tagObj.Tagger
+		case plumbing.ErrObjectNotFound:
+			tag.TargetSHA = ref.Hash().String()
+			tag.TargetType = plumbing.CommitObject.String()
+			commit, e := r.CommitObject(ref.Hash())
+			if e != nil {
+				j.log.WithFields(logrus.Fields{"operation": "GetTags"}).Warningf("cannot get tag %s target %s: %v", tag.Name, tag.TargetSHA, e)
+				return nil
+			}
+			signature = commit.Committer
+		default:
+			return e
+		}
+		tag.TaggedTimestamp = signature.When
+		if signature.Name != "" || signature.Email != "" {
+			tagger := j.signatureIdentity(signature)
+			tag.Tagger = &tagger
+		}
+		tags = append(tags, tag)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	sort.Slice(tags, func(i, k int) bool {
+		return tags[i].TaggedTimestamp.Before(tags[k].TaggedTimestamp)
+	})
+	if ctx.Debug > 0 {
+		j.log.WithFields(logrus.Fields{"operation": "GetTags"}).Debugf("found %d tags", len(tags))
+	}
+	return
+}
+
+// signatureIdentity - return identity object for a git signature (tagger)
+func (j *DSGit) signatureIdentity(signature object.Signature) user.UserIdentityObjectBase {
+	name := strings.TrimSpace(signature.Name)
+	email := strings.TrimSpace(signature.Email)
+	if email != "" {
+		valid, _ := shared.IsValidEmail(email, false, false)
+		if !valid {
+			email = ""
+		}
+	}
+	username := ""
+	id, err := user.GenerateIdentity(&j.RepositorySource, &email, &name, &username)
+	if err != nil {
+		j.log.WithFields(logrus.Fields{"operation": "signatureIdentity"}).Error(fmt.Errorf("GenerateIdentity source: %s, email: %s, name:%s, username:%s. error: %+v", j.RepositorySource, email, name, username, err))
+	}
+	return user.UserIdentityObjectBase{
+		ID:         id,
+		Email:      email,
+		Name:       name,
+		IsVerified: false,
+		Username:   username,
+		Source:     j.RepositorySource,
+	}
+}
+
+// createTagHash - hash of tag fields that trigger tag.updated event when changed
+func createTagHash(tag Tag) (string, error) {
+	tag.SyncTimestamp = time.Time{}
+	b, err := jsoniter.Marshal(tag)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// SyncTags - publish tag.created/tag.updated events for new or changed tags, deduplicated via tags cache
+func (j *DSGit) SyncTags(ctx *shared.Ctx, r *goGit.Repository) (err error) {
+	tags, err := j.GetTags(ctx, r)
+	if err != nil {
+		return
+	}
+	cached := j.getCacheFileByEntityID(tagsCacheFile)
+	baseEvent := service.BaseEvent{
+		CRUDInfo: service.CRUDInfo{
+			CreatedBy: GitConnector,
+			UpdatedBy: GitConnector,
+			CreatedAt: time.Now().Unix(),
+			UpdatedAt: time.Now().Unix(),
+		},
+	}
+	tagBaseEvent := git.CommitBaseEvent{
+		Connector:        insights.GitConnector,
+		ConnectorVersion: GitBackendVersion,
+		Source:           insights.Source(j.RepositorySource),
+	}
+	createdData, updatedData := make([]interface{}, 0), make([]interface{}, 0)
+	createdCache, updatedCache := make([]CommitCache, 0), make([]CommitCache, 0)
+	for _, tag := range tags {
+		hash, e := createTagHash(tag)
+		if e != nil {
+			j.log.WithFields(logrus.Fields{"operation": "SyncTags"}).Errorf("error hashing tag %s: %v", tag.Name, e)
+			continue
+		}
+		prev, ok := cached[tag.ID]
+		if ok && prev.Hash == hash {
+			continue
+		}
+		comm := CommitCache{
+			Timestamp:      fmt.Sprintf("%v", tag.SyncTimestamp.Unix()),
+			EntityID:       tag.ID,
+			SourceEntityID: tag.Name,
+			Hash:           hash,
+			CommitDate:     tag.TaggedTimestamp,
+		}
+		if ok {
+			baseEvent.Type = TagUpdated
+			updatedData = append(updatedData, TagUpdatedEvent{CommitBaseEvent: tagBaseEvent, BaseEvent: baseEvent, Payload: tag})
+			updatedCache = append(updatedCache, comm)
+			continue
+		}
+		baseEvent.Type = TagCreated
+		createdData = append(createdData, TagCreatedEvent{CommitBaseEvent: tagBaseEvent, BaseEvent: baseEvent, Payload: tag})
+		createdCache = append(createdCache, comm)
+	}
+	if err = j.publishCachedEvents(ctx, tagsCacheFile, TagCreated, "tags", createdData, createdCache, cached); err != nil {
+		return
+	}
+	if err = j.publishCachedEvents(ctx, tagsCacheFile, TagUpdated, "tags", updatedData, updatedCache, cached); err != nil {
+		return
+	}
+	j.log.WithFields(logrus.Fields{"operation": "SyncTags"}).Infof("%d tags: %d created, %d updated", len(tags), len(createdData), len(updatedData))
+	return
+}
+
+// publishCachedEvents - publish events in packs, after each pack store entries in the given cache file
+// cached is keyed by entity ID and cache[i] is the cache entry of data[i]
+func (j *DSGit) publishCachedEvents(ctx *shared.Ctx, cacheFile, action, subEventType string, data []interface{}, cache []CommitCache, cached map[string]CommitCache) (err error) {
+	for from := 0; from < len(data); from += ctx.PackSize {
+		to := from + ctx.PackSize
+		if to > len(data) {
+			to = len(data)
+		}
+		var path string
+		if j.Publisher != nil {
+			path, err = j.Publisher.PushEvents(action, "insights", GitDataSource, subEventType, os.Getenv("STAGE"), data[from:to], j.endpoint)
+			if err != nil {
+				return
+			}
+		} else {
+			var jsonBytes []byte
+			jsonBytes, err = jsoniter.Marshal(data[from:to])
+			if err != nil {
+				return
+			}
+			j.log.WithFields(logrus.Fields{"operation": "publishCachedEvents"}).Infof("%s", string(jsonBytes))
+		}
+		for _, comm := range cache[from:to] {
+			comm.FileLocation = path
+			cached[comm.EntityID] = comm
+		}
+		if err = j.updateCacheFile(cacheFile, cached); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// getCacheFileByEntityID - return cache file entries keyed by entity ID, empty when the file does not exist yet
+func (j *DSGit) getCacheFileByEntityID(name string) map[string]CommitCache {
+	cached := make(map[string]CommitCache)
+	byHash, err := j.getCacheFileByKey(name, "")
+	if err != nil {
+		return cached
+	}
+	for _, c := range byHash {
+		cached[c.EntityID] = c
+	}
+	return cached
+}
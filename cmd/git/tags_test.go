@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseSemVer(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		tag      string
+		expected *SemVer
+	}{
+		{name: "plain", tag: "1.2.3", expected: &SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{name: "v prefix", tag: "v1.2.3", expected: &SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{name: "upper case V prefix", tag: "V0.10.0", expected: &SemVer{Minor: 10}},
+		{name: "module path prefix", tag: "module/v1.2.3", expected: &SemVer{Major: 1, Minor: 2, Patch: 3}},
+		{name: "nested module path prefix", tag: "sdk/go/v2.0.1", expected: &SemVer{Major: 2, Patch: 1}},
+		{name: "pre-release", tag: "v1.0.0-rc.1", expected: &SemVer{Major: 1, PreRelease: "rc.1"}},
+		{name: "pre-release with hyphens", tag: "1.0.0-alpha-beta", expected: &SemVer{Major: 1, PreRelease: "alpha-beta"}},
+		{name: "build", tag: "v1.0.0+20220102", expected: &SemVer{Major: 1, Build: "20220102"}},
+		{name: "pre-release and build", tag: "v1.0.0-beta.2+exp.sha.5114f85", expected: &SemVer{Major: 1, PreRelease: "beta.2", Build: "exp.sha.5114f85"}},
+		{name: "empty", tag: ""},
+		{name: "missing patch", tag: "v1.2"},
+		{name: "extra component", tag: "v1.2.3.4"},
+		{name: "leading zero", tag: "v01.2.3"},
+		{name: "leading zero in numeric pre-release", tag: "v1.2.3-01"},
+		{name: "empty pre-release", tag: "v1.2.3-"},
+		{name: "empty build", tag: "v1.2.3+"},
+		{name: "not a version", tag: "release-1"},
+		{name: "trailing slash", tag: "v1.2.3/"},
+		{name: "surrounding text", tag: "version v1.2.3"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseSemVer(tc.tag)
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %+v, got %+v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	tr := newTestRepo(t)
+	a := tr.commitAt("a", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := tr.commitAt("b", time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC), a)
+	tr.setRef("refs/heads/master", b)
+	tr.setRef("refs/tags/v0.1.0", a)
+	// annotated tag helper uses 2022-01-02 03:04:05 as the tagger date
+	annotated := tr.tag("v1.0.0", b, plumbing.CommitObject)
+	tr.setRef("refs/tags/v1.0.0", annotated)
+	tr.setRef("refs/tags/nightly", b)
+	j := &DSGit{
+		URL:              "https://github.com/org/repo",
+		RepositorySource: "git",
+		log:              logrus.NewEntry(logrus.New()),
+	}
+	tags, err := j.GetTags(&shared.Ctx{}, tr.r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type tagInfo struct {
+		name       string
+		targetSHA  string
+		targetType string
+		annotated  bool
+		message    string
+		when       time.Time
+		semver     *SemVer
+	}
+	expected := []tagInfo{
+		{name: "v0.1.0", targetSHA: a.String(), targetType: "commit", when: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), semver: &SemVer{Minor: 1}},
+		{name: "v1.0.0", targetSHA: b.String(), targetType: "commit", annotated: true, message: "v1.0.0", when: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), semver: &SemVer{Major: 1}},
+		{name: "nightly", targetSHA: b.String(), targetType: "commit", when: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	got := make([]tagInfo, 0, len(tags))
+	ids := make(map[string]struct{})
+	for _, tag := range tags {
+		got = append(got, tagInfo{
+			name:       tag.Name,
+			targetSHA:  tag.TargetSHA,
+			targetType: tag.TargetType,
+			annotated:  tag.Annotated,
+			message:    tag.Message,
+			when:       tag.TaggedTimestamp.UTC(),
+			semver:     tag.SemVer,
+		})
+		if tag.RepositoryID == "" {
+			t.Errorf("expected repository ID for tag %s", tag.Name)
+		}
+		if tag.Tagger == nil || tag.Tagger.Name != "Jane Doe" || tag.Tagger.Email != "jane@example.com" {
+			t.Errorf("expected Jane Doe tagger for tag %s, got %+v", tag.Name, tag.Tagger)
+		}
+		ids[tag.ID] = struct{}{}
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if len(ids) != len(tags) {
+		t.Errorf("expected unique tag IDs, got %d for %d tags", len(ids), len(tags))
+	}
+}
+
+func TestTagsCacheFile(t *testing.T) {
+	j := &DSGit{cacheProvider: NewMemoryCache(), endpoint: "github.com-org-repo"}
+	if cached := j.getCacheFileByEntityID(tagsCacheFile); len(cached) != 0 {
+		t.Fatalf("expected empty cache, got %+v", cached)
+	}
+	cache := map[string]CommitCache{
+		"id1": {Timestamp: "1641092645", EntityID: "id1", SourceEntityID: "v1.0.0", FileLocation: "path/1", Hash: "h1"},
+		"id2": {Timestamp: "1641092646", EntityID: "id2", SourceEntityID: "v1.1.0", FileLocation: "path/2", Hash: "h2", Content: "a,\"b\"\nc"},
+	}
+	if err := j.updateCacheFile(tagsCacheFile, cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := j.getCacheFileByEntityID(tagsCacheFile); !reflect.DeepEqual(cache, got) {
+		t.Errorf("expected %+v, got %+v", cache, got)
+	}
+}
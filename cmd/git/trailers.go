@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+	"gopkg.in/yaml.v2"
+)
+
+// TrailerAuthor - authors kind created from a trailer: rich document key and contributor role
+type TrailerAuthor struct {
+	RichKey string `yaml:"rich_key" json:"rich_key"`
+	Role    string `yaml:"role" json:"role"`
+}
+
+// TrailersMapping - trailer mapping overrides, merged over built-in defaults
+// AllowedTrailers maps a (case insensitive) trailer to target trailers, an empty list disables the trailer
+// OtherAuthors maps a target trailer to authors kind, SameAsAuthor tells if it can be the same as commit's author
+type TrailersMapping struct {
+	AllowedTrailers map[string][]string      `yaml:"allowed_trailers" json:"allowed_trailers"`
+	OtherAuthors    map[string]TrailerAuthor `yaml:"other_authors" json:"other_authors"`
+	SameAsAuthor    map[string]bool          `yaml:"same_as_author" json:"same_as_author"`
+}
+
+// TrailersConfig - trailer mapping file, top level mapping applies to all repositories,
+// Projects holds per-project mappings applied on top of it
+type TrailersConfig struct {
+	TrailersMapping `yaml:",inline"`
+	Projects        map[string]TrailersMapping `yaml:"projects" json:"projects"`
+}
+
+// trailerMapping - resolved trailer mapping used while parsing commits
+type trailerMapping struct {
+	allowed      map[string][]string
+	otherAuthors map[string][2]string
+	sameAsAuthor map[string]bool
+}
+
+// ReadTrailersConfig - read YAML or JSON trailer mapping file (JSON is parsed as YAML) and validate it
+// for all projects it defines
+func ReadTrailersConfig(path string) (config *TrailersConfig, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	config = &TrailersConfig{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		err = fmt.Errorf("cannot parse trailers mapping %s: %+v", path, err)
+		return
+	}
+	if _, err = config.resolve(""); err != nil {
+		err = fmt.Errorf("trailers mapping %s: %+v", path, err)
+		return
+	}
+	for project := range config.Projects {
+		if _, err = config.resolve(project); err != nil {
+			err = fmt.Errorf("trailers mapping %s, project %s: %+v", path, project, err)
+			return
+		}
+	}
+	return
+}
+
+// defaultTrailers - return a copy of built-in trailer mapping
+func defaultTrailers() *trailerMapping {
+	t := &trailerMapping{
+		allowed:      make(map[string][]string, len(GitAllowedTrailers)),
+		otherAuthors: make(map[string][2]string, len(GitTrailerOtherAuthors)),
+		sameAsAuthor: make(map[string]bool, len(GitTrailerSameAsAuthor)),
+	}
+	for k, v := range GitAllowedTrailers {
+		t.allowed[k] = v
+	}
+	for k, v := range GitTrailerOtherAuthors {
+		t.otherAuthors[k] = v
+	}
+	for k, v := range GitTrailerSameAsAuthor {
+		t.sameAsAuthor[k] = v
+	}
+	return t
+}
+
+// merge - apply mapping overrides
+func (t *trailerMapping) merge(m TrailersMapping) {
+	for k, v := range m.AllowedTrailers {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if len(v) == 0 {
+			delete(t.allowed, k)
+			continue
+		}
+		t.allowed[k] = v
+	}
+	for k, v := range m.OtherAuthors {
+		t.otherAuthors[k] = [2]string{v.RichKey, v.Role}
+	}
+	for k, v := range m.SameAsAuthor {
+		t.sameAsAuthor[k] = v
+	}
+}
+
+// validate - every target trailer must be either used by built-in mapping or map to an authors kind
+// and every authors kind must have rich key and role set
+func (t *trailerMapping) validate() error {
+	known := make(map[string]struct{})
+	for _, targets := range GitAllowedTrailers {
+		for _, target := range targets {
+			known[target] = struct{}{}
+		}
+	}
+	for target, author := range t.otherAuthors {
+		if author[0] == "" || author[1] == "" {
+			return fmt.Errorf("other author %s must have both rich_key and role set", target)
+		}
+		known[target] = struct{}{}
+	}
+	var missing []string
+	for trailer, targets := range t.allowed {
+		for _, target := range targets {
+			if _, ok := known[target]; !ok {
+				missing = append(missing, trailer+" -> "+target)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("unknown target trailers (add them to other_authors): %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// resolve - return mapping for a given project: defaults, then top level overrides, then project overrides
+func (c *TrailersConfig) resolve(project string) (*trailerMapping, error) {
+	t := defaultTrailers()
+	if c == nil {
+		return t, nil
+	}
+	t.merge(c.TrailersMapping)
+	if m, ok := c.Projects[project]; ok && project != "" {
+		t.merge(m)
+	}
+	return t, t.validate()
+}
+
+// trailers - return trailer mapping of the current sync, built-in defaults when not resolved yet
+func (j *DSGit) trailers() *trailerMapping {
+	if j.state == nil || j.state.trailers == nil {
+		return &trailerMapping{allowed: GitAllowedTrailers, otherAuthors: GitTrailerOtherAuthors, sameAsAuthor: GitTrailerSameAsAuthor}
+	}
+	return j.state.trailers
+}
+
+// ParseMessageTrailers - parse trailers of a commit message built from go-git commit, the same way git log parser does
+// trailers are returned in a separate map, so they do not become commit's trailer authors unless TrailerAuthors is set
+func (j *DSGit) ParseMessageTrailers(ctx *shared.Ctx, message string) map[string]interface{} {
+	trailers := make(map[string]interface{})
+	for _, line := range strings.Split(message, "\n") {
+		j.ParseTrailer(ctx, trailers, strings.TrimSpace(line))
+	}
+	return trailers
+}
+
+// commitTrailer - trailer values of a commit, parsed by git log parser into the commit itself
+// or by ParseMessageTrailers into its message_trailers
+func commitTrailer(commit map[string]interface{}, trailer string) []interface{} {
+	if values, ok := commit[trailer].([]interface{}); ok {
+		return values
+	}
+	trailers, _ := commit["message_trailers"].(map[string]interface{})
+	values, _ := trailers[trailer].([]interface{})
+	return values
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	shared "github.com/LF-Engineering/insights-datasource-shared"
+)
+
+var (
+	// gitDateFormats - date formats used by go-git (RFC1123Z) and by `git log` default and ISO formats
+	gitDateFormats = []string{
+		time.RFC1123Z,
+		"Mon Jan 2 15:04:05 2006 -0700",
+		"2006-01-02 15:04:05 -0700",
+		time.RFC3339,
+	}
+)
+
+// ParseDateWithTz - parse git date keeping its timezone offset to the minute
+// returns UTC date, date in its original timezone, timezone offset in (possibly fractional) hours
+// for example +05:30 gives 5.5 and +05:45 gives 5.75
+func ParseDateWithTz(sDate string) (dateUTC, dateTz time.Time, tz float64, ok bool) {
+	sDate = strings.TrimSpace(sDate)
+	for _, format := range gitDateFormats {
+		dt, err := time.Parse(format, sDate)
+		if err != nil {
+			continue
+		}
+		_, offset := dt.Zone()
+		dateTz = dt.In(fixedZone(offset))
+		dateUTC = dt.UTC()
+		tz = float64(offset) / 3600.0
+		ok = true
+		return
+	}
+	return shared.ParseDateWithTz(sDate)
+}
+
+// fixedZone - return fixed timezone for an offset in seconds, named like UTC+05:30
+func fixedZone(offset int) *time.Location {
+	sign := "+"
+	abs := offset
+	if offset < 0 {
+		sign = "-"
+		abs = -offset
+	}
+	return time.FixedZone(fmt.Sprintf("UTC%s%02d:%02d", sign, abs/3600, (abs%3600)/60), offset)
+}
+
+// enrichDateTz - set timezone, local and UTC date fields of a rich item for a date prefix (author or commit)
+// weekday and hour are taken in the date's own timezone and in UTC
+func enrichDateTz(rich map[string]interface{}, prefix, tzKey string, dateUTC, dateTz time.Time, tz float64) {
+	rich[tzKey] = tz
+	rich[prefix+"_date"] = dateTz
+	rich[prefix+"_local_date"] = dateTz.Format(time.RFC3339)
+	rich[prefix+"_date_weekday"] = int(dateTz.Weekday())
+	rich[prefix+"_date_hour"] = dateTz.Hour()
+	rich["utc_"+prefix] = dateUTC
+	rich["utc_"+prefix+"_date_weekday"] = int(dateUTC.Weekday())
+	rich["utc_"+prefix+"_date_hour"] = dateUTC.Hour()
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateWithTz(t *testing.T) {
+	var testCases = []struct {
+		name       string
+		date       string
+		utc        time.Time
+		zone       string
+		tz         float64
+		localDate  string
+		weekday    time.Weekday
+		hour       int
+		utcWeekday time.Weekday
+		utcHour    int
+	}{
+		{
+			name:       "India +05:30",
+			date:       "Tue, 03 Jan 2023 01:15:00 +0530",
+			utc:        time.Date(2023, 1, 2, 19, 45, 0, 0, time.UTC),
+			zone:       "UTC+05:30",
+			tz:         5.5,
+			localDate:  "2023-01-03T01:15:00+05:30",
+			weekday:    time.Tuesday,
+			hour:       1,
+			utcWeekday: time.Monday,
+			utcHour:    19,
+		},
+		{
+			name:       "Nepal +05:45",
+			date:       "Sun, 01 Jan 2023 00:30:00 +0545",
+			utc:        time.Date(2022, 12, 31, 18, 45, 0, 0, time.UTC),
+			zone:       "UTC+05:45",
+			tz:         5.75,
+			localDate:  "2023-01-01T00:30:00+05:45",
+			weekday:    time.Sunday,
+			hour:       0,
+			utcWeekday: time.Saturday,
+			utcHour:    18,
+		},
+		{
+			name:       "Australia Darwin +09:30",
+			date:       "Wed, 04 Jan 2023 08:00:00 +0930",
+			utc:        time.Date(2023, 1, 3, 22, 30, 0, 0, time.UTC),
+			zone:       "UTC+09:30",
+			tz:         9.5,
+			localDate:  "2023-01-04T08:00:00+09:30",
+			weekday:    time.Wednesday,
+			hour:       8,
+			utcWeekday: time.Tuesday,
+			utcHour:    22,
+		},
+		{
+			name:       "Australia Adelaide summer +10:30",
+			date:       "Thu, 05 Jan 2023 10:29:00 +1030",
+			utc:        time.Date(2023, 1, 4, 23, 59, 0, 0, time.UTC),
+			zone:       "UTC+10:30",
+			tz:         10.5,
+			localDate:  "2023-01-05T10:29:00+10:30",
+			weekday:    time.Thursday,
+			hour:       10,
+			utcWeekday: time.Wednesday,
+			utcHour:    23,
+		},
+		{
+			name:       "Australia Eucla +08:45",
+			date:       "Fri, 06 Jan 2023 08:44:00 +0845",
+			utc:        time.Date(2023, 1, 5, 23, 59, 0, 0, time.UTC),
+			zone:       "UTC+08:45",
+			tz:         8.75,
+			localDate:  "2023-01-06T08:44:00+08:45",
+			weekday:    time.Friday,
+			hour:       8,
+			utcWeekday: time.Thursday,
+			utcHour:    23,
+		},
+		{
+			name:       "Newfoundland -03:30",
+			date:       "Fri, 06 Jan 2023 21:00:00 -0330",
+			utc:        time.Date(2023, 1, 7, 0, 30, 0, 0, time.UTC),
+			zone:       "UTC-03:30",
+			tz:         -3.5,
+			localDate:  "2023-01-06T21:00:00-03:30",
+			weekday:    time.Friday,
+			hour:       21,
+			utcWeekday: time.Saturday,
+			utcHour:    0,
+		},
+		{
+			name:       "Marquesas -09:30",
+			date:       "Sat, 07 Jan 2023 15:00:00 -0930",
+			utc:        time.Date(2023, 1, 8, 0, 30, 0, 0, time.UTC),
+			zone:       "UTC-09:30",
+			tz:         -9.5,
+			localDate:  "2023-01-07T15:00:00-09:30",
+			weekday:    time.Saturday,
+			hour:       15,
+			utcWeekday: time.Sunday,
+			utcHour:    0,
+		},
+		{
+			name:       "UTC",
+			date:       "Sun, 08 Jan 2023 12:00:00 +0000",
+			utc:        time.Date(2023, 1, 8, 12, 0, 0, 0, time.UTC),
+			zone:       "UTC+00:00",
+			tz:         0,
+			localDate:  "2023-01-08T12:00:00Z",
+			weekday:    time.Sunday,
+			hour:       12,
+			utcWeekday: time.Sunday,
+			utcHour:    12,
+		},
+		{
+			name:       "git log default format -03:30",
+			date:       "Fri Jan 6 21:00:00 2023 -0330",
+			utc:        time.Date(2023, 1, 7, 0, 30, 0, 0, time.UTC),
+			zone:       "UTC-03:30",
+			tz:         -3.5,
+			localDate:  "2023-01-06T21:00:00-03:30",
+			weekday:    time.Friday,
+			hour:       21,
+			utcWeekday: time.Saturday,
+			utcHour:    0,
+		},
+		{
+			name:       "git log ISO format +05:45",
+			date:       "2023-01-01 00:30:00 +0545",
+			utc:        time.Date(2022, 12, 31, 18, 45, 0, 0, time.UTC),
+			zone:       "UTC+05:45",
+			tz:         5.75,
+			localDate:  "2023-01-01T00:30:00+05:45",
+			weekday:    time.Sunday,
+			hour:       0,
+			utcWeekday: time.Saturday,
+			utcHour:    18,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dateUTC, dateTz, tz, ok := ParseDateWithTz(tc.date)
+			if !ok {
+				t.Fatalf("cannot parse %s", tc.date)
+			}
+			if !dateUTC.Equal(tc.utc) || dateUTC.Location() != time.UTC {
+				t.Errorf("expected UTC date %v, got %v", tc.utc, dateUTC)
+			}
+			if !dateTz.Equal(tc.utc) {
+				t.Errorf("expected date %v, got %v", tc.utc, dateTz)
+			}
+			if zone, offset := dateTz.Zone(); zone != tc.zone || float64(offset) != tc.tz*3600 {
+				t.Errorf("expected zone %s (%v hours), got %s (%d seconds)", tc.zone, tc.tz, zone, offset)
+			}
+			if tz != tc.tz {
+				t.Errorf("expected tz %v, got %v", tc.tz, tz)
+			}
+			rich := make(map[string]interface{})
+			enrichDateTz(rich, "author", "tz", dateUTC, dateTz, tz)
+			expected := map[string]interface{}{
+				"tz":                      tc.tz,
+				"author_local_date":       tc.localDate,
+				"author_date_weekday":     int(tc.weekday),
+				"author_date_hour":        tc.hour,
+				"utc_author_date_weekday": int(tc.utcWeekday),
+				"utc_author_date_hour":    tc.utcHour,
+			}
+			for key, value := range expected {
+				if rich[key] != value {
+					t.Errorf("expected %s %v, got %v", key, value, rich[key])
+				}
+			}
+		})
+	}
+}
+
+func TestFixedZone(t *testing.T) {
+	var testCases = []struct {
+		offset int
+		name   string
+	}{
+		{0, "UTC+00:00"},
+		{19800, "UTC+05:30"},
+		{20700, "UTC+05:45"},
+		{31500, "UTC+08:45"},
+		{34200, "UTC+09:30"},
+		{37800, "UTC+10:30"},
+		{-12600, "UTC-03:30"},
+		{-34200, "UTC-09:30"},
+		{-1800, "UTC-00:30"},
+	}
+	for _, tc := range testCases {
+		zone, offset := time.Date(2023, 1, 1, 0, 0, 0, 0, fixedZone(tc.offset)).Zone()
+		if zone != tc.name || offset != tc.offset {
+			t.Errorf("offset %d: expected %s, got %s (%d)", tc.offset, tc.name, zone, offset)
+		}
+	}
+}
@@ -5,19 +5,21 @@ go 1.17
 require (
 	github.com/LF-Engineering/insights-datasource-shared v1.5.30-0.20230414034312-36df1857433a
 	github.com/LF-Engineering/lfx-event-schema v0.1.37
+	github.com/ProtonMail/go-crypto v0.0.0-20230217124315-7d5c6f04bbb8
 	github.com/aws/aws-lambda-go v1.27.1
 	github.com/aws/aws-sdk-go v1.42.25
 	github.com/go-git/go-billy/v5 v5.4.1
 	github.com/go-git/go-git/v5 v5.6.0
 	github.com/json-iterator/go v1.1.11
 	github.com/sirupsen/logrus v1.8.1
+	golang.org/x/crypto v0.6.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 replace github.com/go-git/go-git/v5 v5.6.0 => github.com/khalifapro/go-git/v5 v5.7.1
 
 require (
 	github.com/Microsoft/go-winio v0.5.2 // indirect
-	github.com/ProtonMail/go-crypto v0.0.0-20230217124315-7d5c6f04bbb8 // indirect
 	github.com/acomagu/bufpipe v1.0.4 // indirect
 	github.com/avast/retry-go v3.0.0+incompatible // indirect
 	github.com/cloudflare/circl v1.1.0 // indirect
@@ -38,11 +40,9 @@ require (
 	github.com/skeema/knownhosts v1.1.0 // indirect
 	github.com/stretchr/testify v1.7.1 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
-	golang.org/x/crypto v0.6.0 // indirect
 	golang.org/x/net v0.7.0 // indirect
 	golang.org/x/sys v0.5.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )